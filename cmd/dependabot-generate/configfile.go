@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/fredrikaverpil/dependabot-generate/internal/generator"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigFilePath is the repository-level config file which is loaded
+// automatically, if present.
+const defaultConfigFilePath = ".github/dependabot-generate.yml"
+
+// fileConfig mirrors config as it is written in the repository-level config
+// file. Values set through command-line flags take precedence.
+type fileConfig struct {
+	// EntrySettings are inlined, e.g. "labels" is a top-level key.
+	generator.EntrySettings `yaml:",inline"`
+
+	Format         string                                `yaml:"format"`
+	RootPath       string                                `yaml:"root-path"`
+	UpdateInterval string                                `yaml:"update-interval"`
+	Schedule       *generator.Schedule                   `yaml:"schedule"`
+	Grouping       *generator.Grouping                   `yaml:"grouping"`
+	Ecosystems     map[string]generator.EcosystemOptions `yaml:"ecosystems"`
+	Registries     bool                                  `yaml:"private-registries"`
+	CodeOwners     string                                `yaml:"codeowners"`
+	Directories    map[string]generator.EntrySettings    `yaml:"directories"`
+	Rules          []generator.PathRule                  `yaml:"rules"`
+	OutputPath     string                                `yaml:"output-path"`
+	ReportPath     string                                `yaml:"report-path"`
+	ReportOnly     bool                                  `yaml:"report-only"`
+	ExcludePaths   []string                              `yaml:"exclude-paths"`
+	Gitignore      bool                                  `yaml:"respect-gitignore"`
+	TrackedOnly    bool                                  `yaml:"tracked-only"`
+	Workers        int                                   `yaml:"workers"`
+	EcosystemMap   []generator.EcosystemMapEntry         `yaml:"ecosystem-map"`
+	AdditionalYAML string                                `yaml:"additional-yaml"`
+	Check          bool                                  `yaml:"check"`
+	PreserveManual bool                                  `yaml:"preserve-manual"`
+	ManualEntries  []generator.ManualEntry               `yaml:"manual-entries"`
+}
+
+// loadConfigFile reads the config file at path. A missing file is only an
+// error if required is set; otherwise an empty fileConfig is returned.
+func loadConfigFile(path string, required bool) (fileConfig, error) {
+	var fc fileConfig
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !required {
+			return fc, nil
+		}
+		return fc, fmt.Errorf("error reading config file '%s': %w", path, err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&fc); err != nil && !errors.Is(err, io.EOF) {
+		return fc, fmt.Errorf("error parsing config file '%s': %w", path, err)
+	}
+
+	log.Printf("Loaded config file '%s'", path)
+	return fc, nil
+}
+
+// applyConfigFile returns cfg with every value from fc applied, except for
+// those whose flag is present in setFlags.
+func applyConfigFile(cfg config, fc fileConfig, setFlags map[string]bool) config {
+	if fc.Format != "" && !setFlags["format"] {
+		cfg.format = fc.Format
+	}
+	if fc.RootPath != "" && !setFlags["root-path"] {
+		cfg.rootPath = fc.RootPath
+	}
+	if !setFlags["update-interval"] {
+		if fc.UpdateInterval != "" {
+			cfg.updateInterval = fc.UpdateInterval
+		}
+		if fc.Schedule != nil {
+			cfg.schedule = *fc.Schedule
+			if fc.Schedule.Interval != "" {
+				cfg.updateInterval = fc.Schedule.Interval
+			}
+		}
+	}
+	// The grouping flags only override the strategies, keeping the named
+	// groups of the file.
+	if fc.Grouping != nil {
+		grouping := *fc.Grouping
+		if setFlags["grouping"] {
+			grouping.Strategy = cfg.grouping.Strategy
+		}
+		if setFlags["security-grouping"] {
+			var security generator.Grouping
+			if grouping.SecurityUpdates != nil {
+				security = *grouping.SecurityUpdates
+			}
+			security.Strategy = cfg.grouping.SecurityUpdates.Strategy
+			grouping.SecurityUpdates = &security
+		}
+		cfg.grouping = grouping
+	}
+	if fc.Registries && !setFlags["private-registries"] {
+		cfg.registries = true
+	}
+	if fc.CodeOwners != "" && !setFlags["codeowners"] {
+		cfg.codeOwners = fc.CodeOwners
+	}
+	if fc.OutputPath != "" && !setFlags["output-path"] {
+		cfg.outputPath = fc.OutputPath
+	}
+	if fc.ReportPath != "" && !setFlags["report-path"] {
+		cfg.reportPath = fc.ReportPath
+	}
+	if fc.ReportOnly && !setFlags["report-only"] {
+		cfg.reportOnly = true
+	}
+	if fc.ExcludePaths != nil && !setFlags["exclude-paths"] {
+		cfg.excludePaths = fc.ExcludePaths
+	}
+	if fc.Gitignore && !setFlags["respect-gitignore"] {
+		cfg.gitignore = true
+	}
+	if fc.TrackedOnly && !setFlags["tracked-only"] {
+		cfg.trackedOnly = true
+	}
+	if fc.Workers > 0 && !setFlags["workers"] {
+		cfg.workers = fc.Workers
+	}
+	if fc.EcosystemMap != nil && !setFlags["custom-map"] {
+		cfg.customMap = fc.EcosystemMap
+	}
+	if fc.AdditionalYAML != "" && !setFlags["additional-yaml"] {
+		cfg.additionalYAML = fc.AdditionalYAML
+	}
+	if fc.Check && !setFlags["check"] {
+		cfg.check = true
+	}
+	if fc.PreserveManual && !setFlags["preserve-manual"] {
+		cfg.preserveManual = true
+	}
+	cfg.settings = applySettingsFlags(fc.EntrySettings, cfg.settings, setFlags)
+	cfg.directories = fc.Directories
+	cfg.rules = fc.Rules
+	cfg.manualEntries = fc.ManualEntries
+	cfg.ecosystems = fc.Ecosystems
+	return cfg
+}
+
+// applySettingsFlags returns the settings of the config file with the values
+// of the settings flags present in setFlags applied.
+func applySettingsFlags(
+	settings, flagSettings generator.EntrySettings,
+	setFlags map[string]bool,
+) generator.EntrySettings {
+	if setFlags["labels"] {
+		settings.Labels = flagSettings.Labels
+	}
+	if setFlags["commit-message-prefix"] {
+		commitMessage := generator.CommitMessage{}
+		if settings.CommitMessage != nil {
+			commitMessage = *settings.CommitMessage
+		}
+		commitMessage.Prefix = flagSettings.CommitMessage.Prefix
+		settings.CommitMessage = &commitMessage
+	}
+	return settings
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/fredrikaverpil/dependabot-generate/internal/generator"
+)
+
+// writeExplanation writes, for every directory of the scan result, how the
+// rules of the ecosystem map were evaluated and which rule detected each
+// ecosystem, followed by the directories left out of the result. Map entries
+// without any matching file are omitted.
+func writeExplanation(w io.Writer, result *generator.ScanResult, ecosystemMap []generator.EcosystemMapEntry) error {
+	var b strings.Builder
+	for _, dir := range result.Directories {
+		evaluations, err := generator.ExplainDirectory(filepath.Join(result.Root, dir.Path), ecosystemMap)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(&b, "%s\n", dir.Path)
+		for _, evaluation := range evaluations {
+			if evaluation.Winner == "" && !hasMatchingFiles(evaluation) {
+				continue
+			}
+			switch {
+			case evaluation.Winner != "":
+				fmt.Fprintf(&b, "  %s: detected by %s\n", evaluation.Ecosystem, evaluation.Winner)
+			case evaluation.Shadowed:
+				fmt.Fprintf(&b, "  %s: already detected by an earlier entry\n", evaluation.Ecosystem)
+			default:
+				fmt.Fprintf(&b, "  %s: not detected\n", evaluation.Ecosystem)
+			}
+			for _, rule := range evaluation.Rules {
+				fmt.Fprintf(&b, "    %s %s\n", mark(rule.Matched), rule.Rule)
+				for _, check := range rule.Checks {
+					files := "no files"
+					if len(check.Files) > 0 {
+						files = strings.Join(check.Files, ", ")
+					}
+					fmt.Fprintf(&b, "        %s %s %s: %s\n", mark(check.Satisfied), check.Kind, check.Pattern, files)
+				}
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	if len(result.WorkspaceMembers) > 0 {
+		b.WriteString("Workspace members\n")
+		for _, member := range result.WorkspaceMembers {
+			fmt.Fprintf(&b, "  %s: %s collapsed into %s\n", member.Path, member.Ecosystem, member.Root)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(result.Excluded) > 0 {
+		b.WriteString("Excluded directories\n")
+		for _, excluded := range result.Excluded {
+			fmt.Fprintf(&b, "  %s: %s\n", excluded.Path, excluded.Reason)
+		}
+		b.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// hasMatchingFiles reports whether any condition of the evaluated entry
+// matched a file.
+func hasMatchingFiles(evaluation generator.EntryEvaluation) bool {
+	for _, rule := range evaluation.Rules {
+		for _, check := range rule.Checks {
+			if len(check.Files) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// mark renders a condition outcome.
+func mark(ok bool) string {
+	if ok {
+		return "✓"
+	}
+	return "✗"
+}
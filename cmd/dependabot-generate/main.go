@@ -1,26 +1,71 @@
 package main
 
 import (
+	"cmp"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
+	_ "time/tzdata" // Schedule timezones are validated, also where the system has no tz database.
 
 	"github.com/fredrikaverpil/dependabot-generate/internal/generator"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// errStaleConfig is returned in check mode when the file at the output path
+// does not match the generated configuration.
+var errStaleConfig = errors.New("dependabot configuration is out of date")
+
+// Output formats.
+const (
+	formatDependabot = "dependabot"
+	formatRenovate   = "renovate"
 )
 
 type config struct {
+	format         string
 	rootPath       string
 	updateInterval string
+	schedule       generator.Schedule
+	grouping       generator.Grouping
+	registries     bool
+	codeOwners     string
+	settings       generator.EntrySettings
+	directories    map[string]generator.EntrySettings
+	rules          []generator.PathRule
+	ecosystems     map[string]generator.EcosystemOptions
 	outputPath     string
+	reportPath     string
+	reportOnly     bool
 	excludePaths   []string
-	customMapJSON  string
+	gitignore      bool
+	trackedOnly    bool
+	workers        int
+	customMap      []generator.EcosystemMapEntry
 	additionalYAML string
+	check          bool
+	explain        bool
+	preserveManual bool
+	manualEntries  []generator.ManualEntry
 }
 
-func run(cfg config) error {
+// stdoutPath is the output path which writes the configuration to stdout.
+const stdoutPath = "-"
+
+// defaultExcludePaths are the exclude patterns used when the exclude-paths flag
+// is left unset or empty.
+const defaultExcludePaths = "**/.venv,**/node_modules"
+
+// run scans the root path and writes the configuration, or what cfg asks for
+// instead. Output meant for the user, rather than logs, goes to stdout.
+func run(cfg config, stdout io.Writer) error {
+	cfg.format = cmp.Or(cfg.format, formatDependabot)
+	cfg.outputPath = cmp.Or(cfg.outputPath, defaultOutputPath(cfg.format))
 	log.Printf(
 		"Starting dependabot generation with root_path: '%s', update_interval: '%s', output_path: '%s'",
 		cfg.rootPath,
@@ -28,77 +73,416 @@ func run(cfg config) error {
 		cfg.outputPath,
 	)
 
-	ecosystemMap, err := generator.GetEcosystemMap(cfg.customMapJSON)
+	ecosystemMap, err := generator.ExtendEcosystemMap(cfg.customMap)
 	if err != nil {
 		return fmt.Errorf("error getting ecosystem map: %w", err)
 	}
 
 	log.Printf("Scanning for directories with dependency files in '%s'", cfg.rootPath)
-	dirs, err := generator.RecursivelyScanDirectories(cfg.rootPath, cfg.excludePaths, ecosystemMap)
+	result, err := generator.RecursivelyScanDirectories(cfg.rootPath, ecosystemMap, generator.ScanOptions{
+		ExcludePaths:      cfg.excludePaths,
+		RespectGitignore:  cfg.gitignore,
+		TrackedOnly:       cfg.trackedOnly,
+		Workers:           cfg.workers,
+		PrivateRegistries: cfg.registries,
+	})
 	if err != nil {
 		return fmt.Errorf("error scanning directories: %w", err)
 	}
-	log.Printf("Found %d directories with dependency files: %v", len(dirs), dirs)
+	log.Printf("Found %d directories with dependency files: %v", len(result.Directories), result.Paths())
 
-	log.Println("Generating dependabot configuration")
-	configContent, err := generator.GenerateDependabotConfig(
-		cfg.rootPath,
-		dirs,
-		cfg.updateInterval,
-		ecosystemMap,
-		cfg.additionalYAML,
-	)
+	if cfg.reportPath != "" {
+		if err := writeReport(cfg.reportPath, result); err != nil {
+			return err
+		}
+	}
+
+	if cfg.explain {
+		return writeExplanation(stdout, result, ecosystemMap)
+	}
+	if cfg.reportOnly {
+		return nil
+	}
+
+	configContent, err := generateConfig(cfg, result)
 	if err != nil {
 		return fmt.Errorf("error generating config: %w", err)
 	}
 
+	if cfg.outputPath == stdoutPath {
+		if cfg.check {
+			return errors.New("check mode needs an output file, not stdout")
+		}
+		_, err := io.WriteString(stdout, configContent)
+		return err
+	}
+
+	if cfg.check {
+		return checkOutput(cfg.outputPath, configContent, stdout)
+	}
+
 	outputDir := filepath.Dir(cfg.outputPath)
 	//nolint:gosec // The permissions 0o755 are standard for directories and necessary for CI/CD environments.
 	if err := os.MkdirAll(outputDir, 0o755); err != nil {
 		return fmt.Errorf("error creating output directory '%s': %w", outputDir, err)
 	}
 
-	log.Printf("Writing dependabot configuration to '%s'", cfg.outputPath)
+	log.Printf("Writing %s configuration to '%s'", cfg.format, cfg.outputPath)
 	//nolint:gosec // The permissions 0o644 are standard for non-executable files and necessary for CI/CD environments.
 	if err := os.WriteFile(cfg.outputPath, []byte(configContent), 0o644); err != nil {
 		return fmt.Errorf("error writing output file: %w", err)
 	}
 
-	log.Printf("Dependabot configuration generated at '%s'", cfg.outputPath)
+	log.Printf("%s configuration generated at '%s'", cfg.format, cfg.outputPath)
+	return nil
+}
+
+// generateConfig renders the configuration of cfg.format for the scan result.
+func generateConfig(cfg config, result *generator.ScanResult) (string, error) {
+	// The update interval flag takes precedence over the interval of the schedule.
+	schedule := cfg.schedule
+	schedule.Interval = cfg.updateInterval
+
+	opts := generator.Options{
+		Schedule:          schedule,
+		Grouping:          cfg.grouping,
+		Settings:          cfg.settings,
+		Directories:       cfg.directories,
+		Rules:             cfg.rules,
+		Ecosystems:        cfg.ecosystems,
+		PrivateRegistries: cfg.registries,
+		AdditionalYAML:    cfg.additionalYAML,
+		ManualEntries:     cfg.manualEntries,
+	}
+
+	switch cfg.format {
+	case formatDependabot:
+		if cfg.codeOwners != "" {
+			codeOwners, err := generator.ReadCodeOwners(cfg.rootPath)
+			if err != nil {
+				return "", err
+			}
+			if codeOwners == nil {
+				log.Printf("Warning: no CODEOWNERS file found in '%s'", cfg.rootPath)
+			}
+			opts.CodeOwners = codeOwners
+			opts.CodeOwnersAs = cfg.codeOwners
+		}
+		if cfg.preserveManual || len(cfg.manualEntries) > 0 {
+			existingPath := cfg.outputPath
+			if existingPath == stdoutPath {
+				existingPath = defaultOutputPath(cfg.format)
+			}
+			existing, err := readExistingConfig(existingPath)
+			if err != nil {
+				return "", err
+			}
+			opts.Existing = existing
+		}
+		log.Println("Generating dependabot configuration")
+		return generator.GenerateDependabotConfig(result, opts)
+	case formatRenovate:
+		if unsupported := dependabotOnlyOptions(cfg); len(unsupported) > 0 {
+			return "", fmt.Errorf("%s only apply to dependabot", strings.Join(unsupported, ", "))
+		}
+		log.Println("Generating renovate configuration")
+		return generator.GenerateRenovateConfig(result, opts)
+	}
+	return "", fmt.Errorf("unknown format '%s', expected '%s' or '%s'", cfg.format, formatDependabot, formatRenovate)
+}
+
+// dependabotOnlyOptions returns the names of the options set in cfg which the
+// renovate format cannot express. Only schedules carry over to renovate.
+func dependabotOnlyOptions(cfg config) []string {
+	ecosystemGrouping, ecosystemSettings := false, false
+	for _, eco := range cfg.ecosystems {
+		ecosystemGrouping = ecosystemGrouping || eco.Grouping != nil
+		ecosystemSettings = ecosystemSettings || !reflect.ValueOf(eco.EntrySettings).IsZero()
+	}
+
+	var names []string
+	for _, option := range []struct {
+		name string
+		set  bool
+	}{
+		{"grouping", !reflect.ValueOf(cfg.grouping).IsZero() || ecosystemGrouping},
+		{"pull request settings", !reflect.ValueOf(cfg.settings).IsZero() || ecosystemSettings},
+		{"directories", len(cfg.directories) > 0},
+		{"rules", len(cfg.rules) > 0},
+		{"codeowners", cfg.codeOwners != ""},
+		{"private-registries", cfg.registries},
+		{"additional-yaml", cfg.additionalYAML != ""},
+		{"preserve-manual", cfg.preserveManual},
+		{"manual-entries", len(cfg.manualEntries) > 0},
+	} {
+		if option.set {
+			names = append(names, option.name)
+		}
+	}
+	return names
+}
+
+// defaultOutputPath returns the output path of the given format, unless
+// overridden.
+func defaultOutputPath(format string) string {
+	if format == formatRenovate {
+		return "renovate.json"
+	}
+	return ".github/dependabot.yml"
+}
+
+// readExistingConfig parses the dependabot configuration at outputPath. A
+// missing file yields nil.
+func readExistingConfig(outputPath string) (*generator.DependabotConfig, error) {
+	data, err := os.ReadFile(outputPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil //nolint:nilnil // There is no existing configuration to preserve.
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading existing config '%s': %w", outputPath, err)
+	}
+
+	existing, err := generator.ParseDependabotConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing existing config '%s': %w", outputPath, err)
+	}
+	return existing, nil
+}
+
+// checkOutput compares the generated configuration with the file at outputPath.
+// If they differ, a unified diff is written to w and errStaleConfig is returned.
+// A missing output file is treated as empty.
+func checkOutput(outputPath, configContent string, w io.Writer) error {
+	log.Printf("Checking dependabot configuration at '%s'", outputPath)
+	existing, err := os.ReadFile(outputPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error reading output file: %w", err)
+	}
+
+	if string(existing) == configContent {
+		log.Printf("Dependabot configuration at '%s' is up to date", outputPath)
+		return nil
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(existing)),
+		B:        difflib.SplitLines(configContent),
+		FromFile: outputPath,
+		ToFile:   outputPath + " (generated)",
+		Context:  3,
+	})
+	if err != nil {
+		return fmt.Errorf("error computing diff: %w", err)
+	}
+	if _, err := io.WriteString(w, diff); err != nil {
+		return fmt.Errorf("error writing diff: %w", err)
+	}
+	return fmt.Errorf("%w: '%s'", errStaleConfig, outputPath)
+}
+
+// runValidate implements the validate subcommand, which checks existing
+// dependabot configuration files.
+func runValidate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s validate [path ...]\n\n", filepath.Base(os.Args[0]))
+		fmt.Fprintln(fs.Output(), "Validate dependabot configuration files (default \".github/dependabot.yml\").")
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{".github/dependabot.yml"}
+	}
+
+	var errs []error
+	for _, path := range paths {
+		if err := validateFile(path); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		log.Printf("'%s' is valid", path)
+	}
+	return errors.Join(errs...)
+}
+
+// validateFile parses and validates a single dependabot configuration file.
+func validateFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading '%s': %w", path, err)
+	}
+	config, err := generator.ParseDependabotConfig(data)
+	if err != nil {
+		return fmt.Errorf("'%s' is invalid: %w", path, err)
+	}
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("'%s' is invalid:\n%w", path, err)
+	}
 	return nil
 }
 
 func main() {
-	rootPath := flag.String("root-path", ".", "Recursively scan this path for dependency files")
-	updateInterval := flag.String("update-interval", "weekly", "Update interval for dependencies")
-	outputPath := flag.String("output-path", ".github/dependabot.yml", "Output file path")
-	excludePathsStr := flag.String(
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		if err := runValidate(os.Args[2:]); err != nil {
+			log.Fatalf("Validation failed: %v", err)
+		}
+		return
+	}
+
+	cfg, err := configure(os.Args[1:])
+	if err != nil {
+		log.Fatalf("Application failed: %v", err)
+	}
+	if err := run(cfg, os.Stdout); err != nil {
+		log.Fatalf("Application failed: %v", err)
+	}
+}
+
+// configure returns the config of the command-line arguments, with the values
+// of the config file applied where no flag was set.
+func configure(args []string) (config, error) {
+	cfg, configPath, setFlags, err := parseFlags(args)
+	if err != nil {
+		return config{}, err
+	}
+	fc, err := loadConfigFile(cmp.Or(configPath, defaultConfigFilePath), setFlags["config"])
+	if err != nil {
+		return config{}, err
+	}
+	cfg = applyConfigFile(cfg, fc, setFlags)
+
+	// Flags passed with an empty value fall back to the defaults.
+	cfg.rootPath = cmp.Or(cfg.rootPath, ".")
+	cfg.updateInterval = cmp.Or(cfg.updateInterval, "weekly")
+	return cfg, nil
+}
+
+// parseFlags parses the command-line arguments into a config. It also returns
+// the path of the config file and which flags were set.
+func parseFlags(args []string) (config, string, map[string]bool, error) {
+	fs := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ExitOnError)
+	configPath := fs.String("config", "", "Path to the config file (default \""+defaultConfigFilePath+"\")")
+	rootPath := fs.String("root-path", ".", "Recursively scan this path for dependency files")
+	updateInterval := fs.String("update-interval", "weekly", "Update interval for dependencies")
+	format := fs.String("format", "", "Output format, 'dependabot' or 'renovate' (default \"dependabot\")")
+	outputPath := fs.String(
+		"output-path",
+		"",
+		"Output file path, or '-' for stdout (default \".github/dependabot.yml\", or \"renovate.json\" for renovate)",
+	)
+	reportPath := fs.String("report-path", "", "Write a JSON report of the scan to this path")
+	reportOnly := fs.Bool("report-only", false, "Only write the report, not the output file")
+	excludePathsStr := fs.String(
 		"exclude-paths",
-		".venv,node_modules",
-		"Comma-separated string of directories to ignore",
+		defaultExcludePaths,
+		"Comma-separated glob patterns of directories to ignore, relative to the root path",
 	)
-	customMapJSON := flag.String("custom-map", "", "JSON string to extend the default ecosystem map")
-	additionalYAML := flag.String("additional-yaml", "", "YAML string to append to the generated dependabot config")
-	flag.Parse()
-
-	var excludePaths []string
-	if *excludePathsStr != "" {
-		excludePaths = strings.Split(*excludePathsStr, ",")
-		for i, dir := range excludePaths {
-			excludePaths[i] = strings.TrimSpace(dir)
-		}
+	gitignore := fs.Bool("respect-gitignore", false, "Skip directories without files known to git")
+	trackedOnly := fs.Bool("tracked-only", false, "Skip directories without files tracked by git")
+	workers := fs.Int("workers", 0, "Number of directories to scan concurrently (default: number of CPUs)")
+	grouping := fs.String(
+		"grouping",
+		"",
+		"Grouping strategy: 'ecosystem', 'directory', 'all', 'dependency-type' or 'none' (default \"ecosystem\")",
+	)
+	securityGrouping := fs.String(
+		"security-grouping",
+		"",
+		"Grouping strategy of security updates, as for -grouping (default: no groups)",
+	)
+	registries := fs.Bool(
+		"private-registries",
+		false,
+		"Configure the private registries referenced by the scanned files, with placeholder secrets",
+	)
+	labelsStr := fs.String("labels", "", "Comma-separated labels of the pull requests (default \"dependencies\")")
+	commitMessagePrefix := fs.String("commit-message-prefix", "", "Prefix of the commit messages, e.g. 'chore(deps)'")
+	codeOwners := fs.String(
+		"codeowners",
+		"",
+		"Route pull requests to the CODEOWNERS of each directory as 'reviewers', 'assignees' or 'both'",
+	)
+	customMapJSON := fs.String("custom-map", "", "JSON string to extend the default ecosystem map")
+	additionalYAML := fs.String("additional-yaml", "", "YAML string to merge into the generated dependabot config")
+	check := fs.Bool("check", false, "Fail with a diff instead of writing if the output file is out of date")
+	explain := fs.Bool("explain", false, "Print why each directory matched instead of writing the output file")
+	preserveManual := fs.Bool(
+		"preserve-manual",
+		false,
+		"Keep entries of the existing output file marked with '# "+generator.ManualMarker+"'",
+	)
+	if err := fs.Parse(args); err != nil {
+		return config{}, "", nil, err
+	}
+
+	// Flags passed with an empty value count as unset, so that they do not
+	// override the config file.
+	setFlags := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		setFlags[f.Name] = f.Value.String() != ""
+	})
+
+	customMap, err := generator.ParseEcosystemMap(*customMapJSON)
+	if err != nil {
+		return config{}, "", nil, err
 	}
 
 	cfg := config{
+		format:         *format,
 		rootPath:       *rootPath,
 		updateInterval: *updateInterval,
+		grouping:       flagGrouping(*grouping, *securityGrouping),
+		registries:     *registries,
+		codeOwners:     *codeOwners,
+		settings:       flagSettings(*labelsStr, *commitMessagePrefix),
 		outputPath:     *outputPath,
-		excludePaths:   excludePaths,
-		customMapJSON:  *customMapJSON,
+		reportPath:     *reportPath,
+		reportOnly:     *reportOnly,
+		excludePaths:   splitList(cmp.Or(*excludePathsStr, defaultExcludePaths)),
+		gitignore:      *gitignore,
+		trackedOnly:    *trackedOnly,
+		workers:        *workers,
+		customMap:      customMap,
 		additionalYAML: *additionalYAML,
+		check:          *check,
+		explain:        *explain,
+		preserveManual: *preserveManual,
 	}
+	return cfg, *configPath, setFlags, nil
+}
 
-	if err := run(cfg); err != nil {
-		log.Fatalf("Application failed: %v", err)
+// flagGrouping returns the grouping of the grouping flags.
+func flagGrouping(strategy, securityStrategy string) generator.Grouping {
+	grouping := generator.Grouping{Strategy: strategy}
+	if securityStrategy != "" {
+		grouping.SecurityUpdates = &generator.Grouping{Strategy: securityStrategy}
+	}
+	return grouping
+}
+
+// flagSettings returns the pull request settings of the settings flags.
+func flagSettings(labels, commitMessagePrefix string) generator.EntrySettings {
+	var settings generator.EntrySettings
+	if labels != "" {
+		settings.Labels = splitList(labels)
+	}
+	if commitMessagePrefix != "" {
+		settings.CommitMessage = &generator.CommitMessage{Prefix: commitMessagePrefix}
+	}
+	return settings
+}
+
+// splitList splits a comma-separated flag value into its trimmed items.
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	items := strings.Split(value, ",")
+	for i, item := range items {
+		items[i] = strings.TrimSpace(item)
 	}
+	return items
 }
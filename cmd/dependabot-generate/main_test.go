@@ -1,12 +1,35 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
+
+	"github.com/fredrikaverpil/dependabot-generate/internal/generator"
+	"gopkg.in/yaml.v3"
 )
 
+// writeFiles writes the files, keyed by their path relative to root, creating
+// their parent directories.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write file %s: %v", name, err)
+		}
+	}
+}
+
 func TestE2E(t *testing.T) {
 	testCases := []struct {
 		name       string
@@ -17,6 +40,7 @@ func TestE2E(t *testing.T) {
 		{
 			name: "default",
 			files: map[string]string{
+				".github/workflows/ci.yml":        "on: push",
 				"go.mod":                          "module root-project",
 				"project-a/uv.lock":               "",
 				"project-a/pyproject.toml":        "",
@@ -55,15 +79,7 @@ func TestE2E(t *testing.T) {
 			rootDir := t.TempDir()
 			t.Logf("Using temporary directory: %s", rootDir)
 
-			for name, content := range tc.files {
-				filePath := filepath.Join(rootDir, name)
-				if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
-					t.Fatal(err)
-				}
-				if err := os.WriteFile(filePath, []byte(content), 0o644); err != nil {
-					t.Fatalf("Failed to write file %s: %v", name, err)
-				}
-			}
+			writeFiles(t, rootDir, tc.files)
 
 			// 2. Set up arguments for the run
 			outputFile := filepath.Join(rootDir, ".github", "dependabot.yml")
@@ -81,7 +97,7 @@ func TestE2E(t *testing.T) {
 			}
 
 			// 3. Run the application logic
-			if err := run(tc.cfg); err != nil {
+			if err := run(tc.cfg, io.Discard); err != nil {
 				t.Fatalf("run() failed: %v", err)
 			}
 
@@ -109,3 +125,657 @@ func TestE2E(t *testing.T) {
 		})
 	}
 }
+
+func TestCheck(t *testing.T) {
+	rootDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(rootDir, "go.mod"), []byte("module root-project"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	outputFile := filepath.Join(rootDir, ".github", "dependabot.yml")
+	cfg := config{
+		rootPath:       rootDir,
+		updateInterval: "weekly",
+		outputPath:     outputFile,
+		check:          true,
+	}
+
+	// 1. A missing output file is stale.
+	if err := run(cfg, io.Discard); !errors.Is(err, errStaleConfig) {
+		t.Fatalf("Expected errStaleConfig for missing file, but got %v", err)
+	}
+	if _, err := os.Stat(outputFile); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Expected check mode not to write the output file, but got %v", err)
+	}
+
+	// 2. A freshly generated output file is up to date.
+	cfg.check = false
+	if err := run(cfg, io.Discard); err != nil {
+		t.Fatalf("run() failed: %v", err)
+	}
+	cfg.check = true
+	if err := run(cfg, io.Discard); err != nil {
+		t.Fatalf("Expected up to date config to pass check, but got %v", err)
+	}
+
+	// 3. A modified output file is stale and produces a diff.
+	if err := os.WriteFile(outputFile, []byte("version: 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := run(cfg, io.Discard); !errors.Is(err, errStaleConfig) {
+		t.Fatalf("Expected errStaleConfig for modified file, but got %v", err)
+	}
+
+	generated := "version: 2\nupdates: []\n"
+	var diff strings.Builder
+	if err := checkOutput(outputFile, generated, &diff); !errors.Is(err, errStaleConfig) {
+		t.Fatalf("Expected errStaleConfig, but got %v", err)
+	}
+	if !strings.Contains(diff.String(), "+updates: []") {
+		t.Errorf("Expected diff to contain the added line, but got:\n%s", diff.String())
+	}
+}
+
+func TestExplain(t *testing.T) {
+	rootDir := t.TempDir()
+	writeFiles(t, rootDir, map[string]string{
+		"pyproject.toml":          "[project]\n",
+		"uv.lock":                 "",
+		"package.json":            `{"workspaces": ["packages/*"]}`,
+		"packages/a/package.json": "{}",
+		"node_modules/x/go.mod":   "module x",
+	})
+
+	ecosystemMap, err := generator.GetEcosystemMap("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := generator.RecursivelyScanDirectories(rootDir, ecosystemMap, generator.ScanOptions{
+		ExcludePaths: []string{"**/node_modules"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	if err := writeExplanation(&out, result, ecosystemMap); err != nil {
+		t.Fatalf("writeExplanation() failed: %v", err)
+	}
+	for _, expected := range []string{
+		"  uv: detected by heuristics[0]\n",
+		"  pip: not detected\n",
+		"        ✗ absent uv.lock: uv.lock\n",
+		"  npm: detected by patterns\n",
+		"  packages/a: npm collapsed into /\n",
+		"  node_modules: matches exclude pattern '**/node_modules'\n",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected explanation to contain %q, but got:\n%s", expected, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "gomod") {
+		t.Errorf("Expected entries without matching files to be omitted, but got:\n%s", out.String())
+	}
+}
+
+func TestReport(t *testing.T) {
+	rootDir := t.TempDir()
+	writeFiles(t, rootDir, map[string]string{
+		"go.mod":                "module root-project",
+		"web/package.json":      "{}",
+		"node_modules/x/go.mod": "module x",
+	})
+	outputFile := filepath.Join(rootDir, ".github", "dependabot.yml")
+	reportFile := filepath.Join(rootDir, "out", "report.json")
+	cfg := config{
+		rootPath:       rootDir,
+		updateInterval: "weekly",
+		outputPath:     outputFile,
+		excludePaths:   []string{"**/node_modules"},
+		reportPath:     reportFile,
+		reportOnly:     true,
+	}
+
+	// 1. Only the report is written.
+	if err := run(cfg, io.Discard); err != nil {
+		t.Fatalf("run() failed: %v", err)
+	}
+	if _, err := os.Stat(outputFile); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Expected no output file with report-only, but got %v", err)
+	}
+
+	data, err := os.ReadFile(reportFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r struct {
+		Version     string `json:"version"`
+		Root        string `json:"root"`
+		Directories []struct {
+			Path       string `json:"path"`
+			Detections []struct {
+				Ecosystem string   `json:"ecosystem"`
+				Rule      string   `json:"rule"`
+				Files     []string `json:"files"`
+			} `json:"detections"`
+		} `json:"directories"`
+		Excluded []struct {
+			Path string `json:"path"`
+		} `json:"excluded"`
+		WorkspaceMembers []any `json:"workspace-members"`
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		t.Fatalf("Failed to decode report: %v\n%s", err, data)
+	}
+	if r.Version == "" || r.Root != rootDir {
+		t.Errorf("Expected version and root %s, but got %q and %q", rootDir, r.Version, r.Root)
+	}
+	if len(r.Directories) != 2 || r.Directories[1].Path != "web" ||
+		r.Directories[1].Detections[0].Ecosystem != "npm" || r.Directories[1].Detections[0].Files[0] != "package.json" {
+		t.Errorf("Expected directories / and web with their detections, but got %+v", r.Directories)
+	}
+	if len(r.Excluded) != 1 || r.Excluded[0].Path != "node_modules" {
+		t.Errorf("Expected node_modules to be excluded, but got %+v", r.Excluded)
+	}
+	if r.WorkspaceMembers == nil {
+		t.Error("Expected an empty list of workspace members, but got null")
+	}
+
+	// 2. The report is written alongside the output file.
+	cfg.reportOnly = false
+	if err := os.Remove(reportFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := run(cfg, io.Discard); err != nil {
+		t.Fatalf("run() failed: %v", err)
+	}
+	for _, path := range []string{outputFile, reportFile} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected %s to be written, but got %v", path, err)
+		}
+	}
+}
+
+func TestRenovateFormat(t *testing.T) {
+	rootDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(rootDir, "go.mod"), []byte("module root-project"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	outputFile := filepath.Join(rootDir, "renovate.json")
+	cfg := config{
+		format:         formatRenovate,
+		rootPath:       rootDir,
+		updateInterval: "weekly",
+		outputPath:     outputFile,
+	}
+	if err := run(cfg, io.Discard); err != nil {
+		t.Fatalf("run() failed: %v", err)
+	}
+
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var renovate generator.RenovateConfig
+	if err := json.Unmarshal(data, &renovate); err != nil {
+		t.Fatalf("Failed to decode renovate config: %v\n%s", err, data)
+	}
+	if !reflect.DeepEqual(renovate.EnabledManagers, []string{"gomod"}) ||
+		!reflect.DeepEqual(renovate.IncludePaths, []string{"*"}) {
+		t.Errorf("Expected gomod for go.mod, but got %+v", renovate)
+	}
+
+	if defaultOutputPath(formatRenovate) != "renovate.json" {
+		t.Errorf("Expected renovate.json as default output path, but got %s", defaultOutputPath(formatRenovate))
+	}
+
+	cfg.additionalYAML = "- package-ecosystem: gomod"
+	if err := run(cfg, io.Discard); err == nil {
+		t.Error("Expected an error for additional YAML with the renovate format, but got nil")
+	}
+
+	cfg.additionalYAML = ""
+	for _, unsupported := range []config{
+		{grouping: generator.Grouping{Strategy: "all"}},
+		{settings: generator.EntrySettings{Labels: []string{"deps"}}},
+		{ecosystems: map[string]generator.EcosystemOptions{
+			"gomod": {EntrySettings: generator.EntrySettings{RebaseStrategy: "disabled"}},
+		}},
+		{rules: []generator.PathRule{{Path: "services/**"}}},
+		{codeOwners: "reviewers"},
+		{registries: true},
+	} {
+		unsupported.format = cfg.format
+		unsupported.rootPath = cfg.rootPath
+		unsupported.updateInterval = cfg.updateInterval
+		unsupported.outputPath = cfg.outputPath
+		if err := run(unsupported, io.Discard); err == nil || !strings.Contains(err.Error(), "only apply to dependabot") {
+			t.Errorf("Expected an error for dependabot-only options %+v, but got %v", unsupported, err)
+		}
+	}
+
+	withSchedule := cfg
+	withSchedule.ecosystems = map[string]generator.EcosystemOptions{
+		"gomod": {Schedule: &generator.Schedule{Interval: "monthly"}},
+	}
+	if err := run(withSchedule, io.Discard); err != nil {
+		t.Errorf("Expected per-ecosystem schedules to apply to renovate, but got %v", err)
+	}
+
+	cfg.format = "jenkins"
+	if err := run(cfg, io.Discard); err == nil {
+		t.Error("Expected an error for an unknown format, but got nil")
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	rootDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(rootDir, "go.mod"), []byte("module root-project"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config{
+		rootPath:       rootDir,
+		updateInterval: "weekly",
+		outputPath:     stdoutPath,
+	}
+
+	var stdout strings.Builder
+	if err := run(cfg, &stdout); err != nil {
+		t.Fatalf("run() failed: %v", err)
+	}
+	if !strings.HasPrefix(stdout.String(), "version: 2\nupdates:\n  - package-ecosystem: gomod\n") {
+		t.Errorf("Expected the generated config on stdout, but got:\n%s", stdout.String())
+	}
+	if _, err := os.Stat(stdoutPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected no file named '%s' to be written, but got %v", stdoutPath, err)
+	}
+
+	cfg.check = true
+	if err := run(cfg, io.Discard); err == nil {
+		t.Error("Expected an error for check mode on stdout, but got nil")
+	}
+}
+
+func TestConfigFile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "dependabot-generate.yml")
+	content := `update-interval: monthly
+schedule:
+  day: monday
+ecosystems:
+  gomod:
+    schedule:
+      interval: daily
+exclude-paths:
+  - .tools
+workers: 4
+private-registries: true
+codeowners: assignees
+labels: ["deps"]
+ignore:
+  - dependency-name: "k8s.io/*"
+    update-types: ["version-update:semver-major"]
+commit-message:
+  prefix: "chore(deps)"
+  include: scope
+directories:
+  tools:
+    open-pull-requests-limit: 0
+rules:
+  - path: "services/payments/**"
+    ecosystem: gomod
+    schedule:
+      interval: daily
+    reviewers: ["acme/payments"]
+grouping:
+  strategy: all
+  groups:
+    aws-sdk:
+      patterns: ["@aws-sdk/*"]
+ecosystem-map:
+  - ecosystem: gomod
+    heuristics:
+      - present: ["go.work"]
+additional-yaml: |
+  - package-ecosystem: "gomod"
+`
+	if err := os.WriteFile(configPath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fc, err := loadConfigFile(configPath, true)
+	if err != nil {
+		t.Fatalf("loadConfigFile() failed: %v", err)
+	}
+
+	defaults := config{
+		rootPath:       ".",
+		updateInterval: "weekly",
+		outputPath:     ".github/dependabot.yml",
+		excludePaths:   []string{".venv", "node_modules"},
+	}
+
+	t.Run("file values apply", func(t *testing.T) {
+		cfg := applyConfigFile(defaults, fc, map[string]bool{})
+		if cfg.updateInterval != "monthly" {
+			t.Errorf("Expected update interval 'monthly', but got '%s'", cfg.updateInterval)
+		}
+		if cfg.schedule.Day != "monday" {
+			t.Errorf("Expected schedule day 'monday', but got '%s'", cfg.schedule.Day)
+		}
+		if cfg.ecosystems["gomod"].Schedule.Interval != "daily" {
+			t.Errorf("Expected gomod interval 'daily', but got %+v", cfg.ecosystems["gomod"])
+		}
+		if cfg.rootPath != "." {
+			t.Errorf("Expected root path '.', but got '%s'", cfg.rootPath)
+		}
+		if len(cfg.excludePaths) != 1 || cfg.excludePaths[0] != ".tools" {
+			t.Errorf("Expected exclude paths [.tools], but got %v", cfg.excludePaths)
+		}
+		if len(cfg.customMap) != 1 || cfg.customMap[0].Heuristics[0].Present[0] != "go.work" {
+			t.Errorf("Expected ecosystem map from file, but got %+v", cfg.customMap)
+		}
+		if !strings.HasPrefix(cfg.additionalYAML, "- package-ecosystem") {
+			t.Errorf("Expected additional YAML from file, but got %q", cfg.additionalYAML)
+		}
+		if cfg.workers != 4 {
+			t.Errorf("Expected 4 workers, but got %d", cfg.workers)
+		}
+		if !cfg.registries {
+			t.Error("Expected private registries from file, but got false")
+		}
+		if cfg.codeOwners != "assignees" {
+			t.Errorf("Expected code owners 'assignees', but got '%s'", cfg.codeOwners)
+		}
+		if len(cfg.settings.Labels) != 1 || cfg.settings.CommitMessage.Prefix != "chore(deps)" ||
+			len(cfg.settings.Ignore) != 1 {
+			t.Errorf("Expected settings from file, but got %+v", cfg.settings)
+		}
+		if limit := cfg.directories["tools"].OpenPullRequestsLimit; limit == nil || *limit != 0 {
+			t.Errorf("Expected directory settings from file, but got %+v", cfg.directories)
+		}
+		if len(cfg.rules) != 1 || cfg.rules[0].Schedule.Interval != "daily" || len(cfg.rules[0].Reviewers) != 1 {
+			t.Errorf("Expected rules from file, but got %+v", cfg.rules)
+		}
+		if cfg.grouping.Strategy != "all" || len(cfg.grouping.Groups) != 1 {
+			t.Errorf("Expected grouping from file, but got %+v", cfg.grouping)
+		}
+	})
+
+	t.Run("flags override file values", func(t *testing.T) {
+		cfg := applyConfigFile(defaults, fc, map[string]bool{"update-interval": true, "exclude-paths": true})
+		if cfg.updateInterval != "weekly" {
+			t.Errorf("Expected update interval 'weekly', but got '%s'", cfg.updateInterval)
+		}
+		if cfg.schedule.Day != "" {
+			t.Errorf("Expected the file schedule to be overridden, but got day '%s'", cfg.schedule.Day)
+		}
+		if len(cfg.excludePaths) != 2 {
+			t.Errorf("Expected default exclude paths, but got %v", cfg.excludePaths)
+		}
+
+		withWorkers := defaults
+		withWorkers.workers = 2
+		if cfg := applyConfigFile(withWorkers, fc, map[string]bool{"workers": true}); cfg.workers != 2 {
+			t.Errorf("Expected 2 workers from the flag, but got %d", cfg.workers)
+		}
+
+		withSettings := defaults
+		withSettings.settings = flagSettings("go,deps", "build(deps)")
+		cfg = applyConfigFile(withSettings, fc, map[string]bool{"labels": true, "commit-message-prefix": true})
+		if !slices.Equal(cfg.settings.Labels, []string{"go", "deps"}) {
+			t.Errorf("Expected labels from the flag, but got %v", cfg.settings.Labels)
+		}
+		if *cfg.settings.CommitMessage != (generator.CommitMessage{Prefix: "build(deps)", Include: "scope"}) {
+			t.Errorf("Expected the prefix from the flag and include from the file, but got %+v", cfg.settings.CommitMessage)
+		}
+
+		withGrouping := defaults
+		withGrouping.grouping.Strategy = "none"
+		withGrouping.grouping.SecurityUpdates = &generator.Grouping{Strategy: "ecosystem"}
+		cfg = applyConfigFile(withGrouping, fc, map[string]bool{"grouping": true, "security-grouping": true})
+		if cfg.grouping.Strategy != "none" || len(cfg.grouping.Groups) != 1 {
+			t.Errorf("Expected the strategy from the flag and groups from the file, but got %+v", cfg.grouping)
+		}
+		if cfg.grouping.SecurityUpdates == nil || cfg.grouping.SecurityUpdates.Strategy != "ecosystem" {
+			t.Errorf("Expected the security updates strategy from the flag, but got %+v", cfg.grouping.SecurityUpdates)
+		}
+	})
+
+	t.Run("missing optional file", func(t *testing.T) {
+		if _, err := loadConfigFile(filepath.Join(t.TempDir(), "missing.yml"), false); err != nil {
+			t.Errorf("Expected no error for missing optional file, but got %v", err)
+		}
+		if _, err := loadConfigFile(filepath.Join(t.TempDir(), "missing.yml"), true); err == nil {
+			t.Error("Expected an error for missing required file, but got nil")
+		}
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		badPath := filepath.Join(t.TempDir(), "bad.yml")
+		if err := os.WriteFile(badPath, []byte("update-intervall: daily\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadConfigFile(badPath, true); err == nil {
+			t.Error("Expected an error for unknown key, but got nil")
+		}
+	})
+}
+
+// actionArgs returns the arguments the composite action passes with all
+// inputs left at their defaults, without the empty ones which entrypoint.sh
+// drops.
+func actionArgs(t *testing.T) []string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "..", "action.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var action struct {
+		Inputs map[string]struct {
+			Default string `yaml:"default"`
+		} `yaml:"inputs"`
+		Runs struct {
+			Args []string `yaml:"args"`
+		} `yaml:"runs"`
+	}
+	if err := yaml.Unmarshal(data, &action); err != nil {
+		t.Fatal(err)
+	}
+
+	args := make([]string, 0, len(action.Runs.Args))
+	for _, arg := range action.Runs.Args {
+		for name, input := range action.Inputs {
+			arg = strings.ReplaceAll(arg, "${{ inputs."+name+" }}", input.Default)
+		}
+		if strings.Contains(arg, "${{") {
+			t.Fatalf("Unknown input in action argument '%s'", arg)
+		}
+		if strings.HasSuffix(arg, "=") {
+			continue
+		}
+		args = append(args, arg)
+	}
+	return args
+}
+
+func TestActionDefaults(t *testing.T) {
+	args := actionArgs(t)
+	testCases := []struct {
+		name        string
+		configFile  string
+		flags       []string
+		expected    []string
+		notExpected []string
+	}{
+		{
+			name:        "without config file",
+			expected:    []string{"interval: weekly"},
+			notExpected: []string{"node_modules"},
+		},
+		{
+			name:       "config file values apply",
+			configFile: "update-interval: monthly\nprivate-registries: true\n",
+			expected:   []string{"interval: monthly", "registries:"},
+		},
+		{
+			name:        "flags with default values override file values",
+			configFile:  "update-interval: monthly\nprivate-registries: true\n",
+			flags:       []string{"--update-interval=weekly", "--private-registries=false"},
+			expected:    []string{"interval: weekly"},
+			notExpected: []string{"registries:"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rootDir := t.TempDir()
+			t.Chdir(rootDir)
+			files := map[string]string{
+				"package.json":                       "{}",
+				"node_modules/left-pad/package.json": "{}",
+				".npmrc":                             "registry=https://npm.example.com/\n",
+			}
+			if tc.configFile != "" {
+				files[defaultConfigFilePath] = tc.configFile
+			}
+			writeFiles(t, ".", files)
+
+			cfg, err := configure(append(slices.Clone(args), tc.flags...))
+			if err != nil {
+				t.Fatalf("configure() failed: %v", err)
+			}
+			if err := run(cfg, io.Discard); err != nil {
+				t.Fatalf("run() failed: %v", err)
+			}
+
+			generated, err := os.ReadFile(defaultOutputPath(formatDependabot))
+			if err != nil {
+				t.Fatalf("Failed to read generated file: %v", err)
+			}
+			for _, expected := range tc.expected {
+				if !strings.Contains(string(generated), expected) {
+					t.Errorf("Expected the config to contain '%s', but got:\n%s", expected, generated)
+				}
+			}
+			for _, notExpected := range tc.notExpected {
+				if strings.Contains(string(generated), notExpected) {
+					t.Errorf("Expected the config not to contain '%s', but got:\n%s", notExpected, generated)
+				}
+			}
+		})
+	}
+}
+
+func TestPreserveManual(t *testing.T) {
+	rootDir := t.TempDir()
+	files := map[string]string{
+		"go.mod":                   "module root-project",
+		"tools/go.mod":             "module tools",
+		"project-a/uv.lock":        "",
+		"project-a/pyproject.toml": "",
+		".github/dependabot.yml": `version: 2
+updates:
+  - package-ecosystem: uv
+    directories: ["project-a", "project-b"]
+    schedule:
+      interval: daily
+  # dependabot-generate: manual
+  - package-ecosystem: gomod
+    directory: /tools
+    schedule:
+      interval: monthly
+`,
+	}
+	writeFiles(t, rootDir, files)
+
+	outputFile := filepath.Join(rootDir, ".github", "dependabot.yml")
+	cfg := config{
+		rootPath:       rootDir,
+		updateInterval: "weekly",
+		outputPath:     outputFile,
+		preserveManual: true,
+	}
+	if err := run(cfg, io.Discard); err != nil {
+		t.Fatalf("run() failed: %v", err)
+	}
+
+	generatedBytes, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+	generated, err := generator.ParseDependabotConfig(generatedBytes)
+	if err != nil {
+		t.Fatalf("Failed to parse generated file: %v", err)
+	}
+
+	var manual, gomod, uv []generator.Update
+	for _, update := range generated.Updates {
+		switch {
+		case update.Manual:
+			manual = append(manual, update)
+		case update.PackageEcosystem == "gomod":
+			gomod = append(gomod, update)
+		case update.PackageEcosystem == "uv":
+			uv = append(uv, update)
+		}
+	}
+	if len(manual) != 1 || manual[0].Directory != "/tools" || manual[0].Schedule.Interval != "monthly" {
+		t.Errorf("Expected the manual gomod entry to be preserved, but got %+v", manual)
+	}
+	if len(gomod) != 1 || !reflect.DeepEqual(gomod[0].Directories, []string{"/"}) {
+		t.Errorf("Expected generated gomod entry without /tools, but got %+v", gomod)
+	}
+	if len(uv) != 1 || uv[0].Schedule.Interval != "weekly" {
+		t.Errorf("Expected unmarked uv entry to be regenerated, but got %+v", uv)
+	}
+
+	// Running again keeps the marker and thus the manual entry.
+	if err := run(cfg, io.Discard); err != nil {
+		t.Fatalf("run() failed: %v", err)
+	}
+	rerunBytes, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+	if string(rerunBytes) != string(generatedBytes) {
+		t.Errorf("Expected stable output on rerun.\nFirst:\n%s\n\nSecond:\n%s", generatedBytes, rerunBytes)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	validPath := filepath.Join(dir, "valid.yml")
+	invalidPath := filepath.Join(dir, "invalid.yml")
+	files := map[string]string{
+		validPath: `version: 2
+updates:
+  - package-ecosystem: gomod
+    directory: /
+    schedule:
+      interval: weekly
+`,
+		invalidPath: `version: 2
+updates:
+  - package-ecosystem: gomod
+    directory: /
+    schedule:
+      interval: weekly
+      day: someday
+`,
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := runValidate([]string{validPath}); err != nil {
+		t.Errorf("Expected valid file to pass, but got %v", err)
+	}
+	err := runValidate([]string{validPath, invalidPath})
+	if err == nil || !strings.Contains(err.Error(), "unknown day 'someday'") {
+		t.Errorf("Expected invalid day to be reported, but got %v", err)
+	}
+	if err := runValidate([]string{filepath.Join(dir, "missing.yml")}); err == nil {
+		t.Error("Expected an error for a missing file, but got nil")
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+
+	"github.com/fredrikaverpil/dependabot-generate/internal/generator"
+)
+
+// version is the version of dependabot-generate. Release builds set it
+// through -ldflags "-X main.version=..."; otherwise the module version from
+// the build info is used.
+var version = "" //nolint:gochecknoglobals // Set at build time through -ldflags.
+
+// report is the machine-readable JSON report of a scan.
+type report struct {
+	Version string `json:"version"`
+	*generator.ScanResult
+}
+
+// getVersion returns the version of dependabot-generate.
+func getVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+// writeReport writes the JSON report of the scan result to reportPath. Empty
+// lists are written as such rather than as null.
+func writeReport(reportPath string, result *generator.ScanResult) error {
+	r := report{Version: getVersion(), ScanResult: &generator.ScanResult{
+		Root:             result.Root,
+		Directories:      nonNil(result.Directories),
+		Excluded:         nonNil(result.Excluded),
+		WorkspaceMembers: nonNil(result.WorkspaceMembers),
+		Registries:       nonNil(result.Registries),
+	}}
+	content, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding report: %w", err)
+	}
+
+	//nolint:gosec // The permissions 0o755 are standard for directories and necessary for CI/CD environments.
+	if err := os.MkdirAll(filepath.Dir(reportPath), 0o755); err != nil {
+		return fmt.Errorf("error creating report directory: %w", err)
+	}
+
+	log.Printf("Writing scan report to '%s'", reportPath)
+	//nolint:gosec // The permissions 0o644 are standard for non-executable files and necessary for CI/CD environments.
+	if err := os.WriteFile(reportPath, append(content, '\n'), 0o644); err != nil {
+		return fmt.Errorf("error writing report: %w", err)
+	}
+	return nil
+}
+
+// nonNil returns s, or an empty slice if s is nil.
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
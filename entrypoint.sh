@@ -4,4 +4,14 @@
 # It executes the compiled Go binary, passing along all the
 # command-line arguments it received.
 
+# The action passes every input as "--name=value". Inputs left empty are
+# dropped, so that they keep the values of the config file.
+for arg in "$@"; do
+	shift
+	case "$arg" in
+	--*=) ;;
+	*) set -- "$@" "$arg" ;;
+	esac
+done
+
 /app/dependabot-generate "$@"
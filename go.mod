@@ -1,3 +1,10 @@
 module github.com/fredrikaverpil/dependabot-generate
 
 go 1.24.4
+
+require (
+	github.com/BurntSushi/toml v1.5.0
+	github.com/bmatcuk/doublestar/v4 v4.9.1
+	github.com/pmezard/go-difflib v1.0.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,159 @@
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"log" //nolint:depguard // No need for slog just yet.
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// CodeOwners assignment modes, see Options.CodeOwnersAs.
+const (
+	CodeOwnersAsReviewers = "reviewers"
+	CodeOwnersAsAssignees = "assignees"
+	CodeOwnersAsBoth      = "both"
+)
+
+// codeOwnersPaths are the locations GitHub reads CODEOWNERS from, in order of
+// precedence.
+func codeOwnersPaths() []string {
+	return []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+}
+
+// CodeOwners is a parsed CODEOWNERS file.
+type CodeOwners struct {
+	rules []codeOwnersRule
+}
+
+// codeOwnersRule is a single line of a CODEOWNERS file.
+type codeOwnersRule struct {
+	// glob is the doublestar glob of the pattern, anchored at the root.
+	glob string
+	// directoryOnly is set for patterns ending in "/", which match the
+	// contents of directories only.
+	directoryOnly bool
+	// directChildren is set for patterns ending in "/*", which match the files
+	// directly in a directory, but not those of its subdirectories.
+	directChildren bool
+	owners         []string
+}
+
+// ReadCodeOwners reads the CODEOWNERS file of the repository at root from
+// .github/, the root or docs/, whichever comes first. It returns nil if there
+// is none.
+func ReadCodeOwners(root string) (*CodeOwners, error) {
+	for _, p := range codeOwnersPaths() {
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(p)))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", p, err)
+		}
+		log.Printf("Loaded code owners from '%s'", p)
+		return ParseCodeOwners(data), nil
+	}
+	return nil, nil //nolint:nilnil // A missing CODEOWNERS file is not an error.
+}
+
+// ParseCodeOwners parses the contents of a CODEOWNERS file. Lines with
+// invalid patterns are skipped with a warning.
+func ParseCodeOwners(data []byte) *CodeOwners {
+	c := &CodeOwners{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		pattern := fields[0]
+		rule := codeOwnersRule{
+			directoryOnly:  strings.HasSuffix(pattern, "/"),
+			directChildren: strings.HasSuffix(pattern, "/*"),
+		}
+		// As in .gitignore, patterns with a slash other than a trailing one
+		// are anchored at the root.
+		anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+		rule.glob = strings.Trim(pattern, "/")
+		if !anchored {
+			rule.glob = "**/" + rule.glob
+		}
+		if !doublestar.ValidatePattern(rule.glob) {
+			log.Printf("Warning: skipping invalid CODEOWNERS pattern '%s'", pattern)
+			continue
+		}
+		for _, owner := range fields[1:] {
+			if strings.HasPrefix(owner, "#") {
+				break
+			}
+			rule.owners = append(rule.owners, owner)
+		}
+		c.rules = append(c.rules, rule)
+	}
+	return c
+}
+
+// Owners returns the owners of a file, a slash-separated path relative to
+// the root, as given by the last matching rule. A rule without owners leaves
+// the file without owners.
+func (c *CodeOwners) Owners(file string) []string {
+	for _, rule := range slices.Backward(c.rules) {
+		if rule.matches(file) {
+			return rule.owners
+		}
+	}
+	return nil
+}
+
+// matches reports whether the rule applies to the file, either through the
+// file itself or through one of its parent directories.
+func (r codeOwnersRule) matches(file string) bool {
+	if !r.directoryOnly {
+		if ok, _ := doublestar.Match(r.glob, file); ok {
+			return true
+		}
+	}
+	if r.directChildren {
+		return false
+	}
+	for dir := path.Dir(file); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if ok, _ := doublestar.Match(r.glob, dir); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// reviewersAndAssignees converts code owners to dependabot reviewers and
+// assignees according to mode. Owners are given as "@user", "@org/team" or
+// an email address; reviewers are users and teams without the "@", while
+// assignees can only be users. Email addresses are left out.
+func reviewersAndAssignees(owners []string, mode string) ([]string, []string) {
+	var users, usersAndTeams []string
+	for _, owner := range owners {
+		name, ok := strings.CutPrefix(owner, "@")
+		if !ok {
+			continue
+		}
+		usersAndTeams = append(usersAndTeams, name)
+		if !strings.Contains(name, "/") {
+			users = append(users, name)
+		}
+	}
+
+	switch mode {
+	case CodeOwnersAsAssignees:
+		return nil, users
+	case CodeOwnersAsBoth:
+		return usersAndTeams, users
+	}
+	return usersAndTeams, nil
+}
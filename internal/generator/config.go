@@ -1,49 +1,193 @@
 package generator
 
 import (
+	"errors"
 	"fmt"
-	"path/filepath"
+	"maps"
+	"reflect"
+	"slices"
 	"sort"
-	"strings"
 )
 
-func GenerateDependabotConfig(
-	scanPath string,
-	directories []string,
-	interval string,
-	ecosystemMap []EcosystemMapEntry,
-	additionalYAML string,
-) (string, error) {
-	ecosystemDirs := make(map[string][]string)
-
-	for _, dir := range directories {
-		// The 'dir' is relative to the original scanPath. We need to join them
-		// to get the correct absolute path for os.ReadDir to work.
-		absoluteDir := filepath.Join(scanPath, dir)
-
-		detected, err := DetectPackageEcosystems(absoluteDir, ecosystemMap)
+// Options controls how the dependabot configuration is generated.
+type Options struct {
+	// Schedule is the schedule of every update entry, unless overridden for
+	// its ecosystem.
+	Schedule Schedule
+	// Grouping is the grouping policy of every update entry, unless
+	// overridden for its ecosystem.
+	Grouping Grouping
+	// Settings are the pull request settings of every update entry, unless
+	// overridden for its ecosystem or directory. Labels default to
+	// "dependencies" and the allow-list to all dependency types, except
+	// for github-actions.
+	Settings EntrySettings
+	// Directories holds per-directory settings, keyed by directory relative
+	// to the root, e.g. "/" or "services/api". They take precedence over
+	// those of the ecosystem.
+	Directories map[string]EntrySettings
+	// Rules override the options of the directories matching their path,
+	// optionally for a single ecosystem. They take precedence over all
+	// other options, with later rules taking precedence over earlier ones.
+	Rules []PathRule
+	// Ecosystems holds per-ecosystem options, keyed by package-ecosystem.
+	Ecosystems map[string]EcosystemOptions
+	// PrivateRegistries adds the private registries of the scan result to the
+	// top-level registries, with placeholder secrets, and to the update
+	// entries of their ecosystems whose directories, or parents thereof,
+	// reference them. The scan must have been run with the option as well.
+	PrivateRegistries bool
+	// CodeOwners, if set, routes the pull requests of every update entry to
+	// the code owners of its directories, see CodeOwnersAs. Update entries
+	// are split by owners.
+	CodeOwners *CodeOwners
+	// CodeOwnersAs is "reviewers", the default, "assignees" or "both".
+	CodeOwnersAs string
+	// AdditionalYAML is merged into the generated configuration, see
+	// MergeAdditionalYAML.
+	AdditionalYAML string
+	// Existing is the current dependabot configuration, whose manually
+	// managed entries are preserved, see PreserveManualEntries.
+	Existing *DependabotConfig
+	// ManualEntries are entries of Existing which are manually managed, in
+	// addition to those marked with a ManualMarker comment.
+	ManualEntries []ManualEntry
+}
+
+// EcosystemOptions overrides Options for the update entries of a single ecosystem.
+type EcosystemOptions struct {
+	Schedule *Schedule `yaml:"schedule,omitempty"`
+	// Grouping replaces Options.Grouping as a whole.
+	Grouping *Grouping `yaml:"grouping,omitempty"`
+	// EntrySettings override Options.Settings field by field.
+	EntrySettings `yaml:",inline"`
+}
+
+// Validate checks the options for values Dependabot would reject.
+func (o Options) Validate() error {
+	var errs []error
+	if err := o.Schedule.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("invalid schedule: %w", err))
+	}
+	if err := o.Grouping.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("invalid grouping: %w", err))
+	}
+	if !oneOf(o.CodeOwnersAs, "", CodeOwnersAsReviewers, CodeOwnersAsAssignees, CodeOwnersAsBoth) {
+		errs = append(errs, fmt.Errorf("unknown code owners mode '%s'", o.CodeOwnersAs))
+	}
+
+	for i, rule := range o.Rules {
+		if err := rule.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("invalid rule %d: %w", i, err))
+		}
+	}
+
+	ecosystems := slices.Sorted(maps.Keys(o.Ecosystems))
+	for _, eco := range ecosystems {
+		if schedule := o.Ecosystems[eco].Schedule; schedule != nil {
+			if err := schedule.Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("invalid schedule for %s: %w", eco, err))
+			}
+		}
+		if grouping := o.Ecosystems[eco].Grouping; grouping != nil {
+			if err := grouping.Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("invalid grouping for %s: %w", eco, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// scheduleFor returns the schedule of the ecosystem in the directory.
+func (o Options) scheduleFor(ecosystem, directory string) Schedule {
+	for _, rule := range slices.Backward(o.Rules) {
+		if rule.Schedule != nil && rule.matches(ecosystem, directory) {
+			return *rule.Schedule
+		}
+	}
+	if schedule := o.Ecosystems[ecosystem].Schedule; schedule != nil {
+		return *schedule
+	}
+	return o.Schedule
+}
+
+// settingsFor returns the settings of the ecosystem in the directory: the
+// defaults, overridden by Settings, then by the settings of the ecosystem, by
+// those of the directory and finally by those of the matching rules.
+func (o Options) settingsFor(ecosystem, directory string) EntrySettings {
+	settings := defaultSettings(ecosystem).overlay(o.Settings).overlay(o.Ecosystems[ecosystem].EntrySettings)
+	for dir, dirSettings := range o.Directories {
+		if directoryKey(dir) == directoryKey(directory) {
+			settings = settings.overlay(dirSettings)
+		}
+	}
+	for _, rule := range o.Rules {
+		if rule.matches(ecosystem, directory) {
+			settings = settings.overlay(rule.EntrySettings)
+		}
+	}
+	return settings
+}
+
+// groupingFor returns the grouping policy of the ecosystem in the directory.
+func (o Options) groupingFor(ecosystem, directory string) Grouping {
+	for _, rule := range slices.Backward(o.Rules) {
+		if rule.Grouping != nil && rule.matches(ecosystem, directory) {
+			return *rule.Grouping
+		}
+	}
+	if grouping := o.Ecosystems[ecosystem].Grouping; grouping != nil {
+		return *grouping
+	}
+	return o.Grouping
+}
+
+// GenerateDependabotConfig builds the dependabot configuration for the
+// directories of a scan, applies the customizations of opts and renders it as
+// YAML.
+func GenerateDependabotConfig(result *ScanResult, opts Options) (string, error) {
+	config, err := BuildDependabotConfig(result, opts)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.AdditionalYAML != "" {
+		config, err = MergeAdditionalYAML(config, opts.AdditionalYAML)
 		if err != nil {
 			return "", err
 		}
-		for _, eco := range detected {
-			ecosystemDirs[eco] = append(ecosystemDirs[eco], dir)
-		}
 	}
 
-	var sb strings.Builder
-	fmt.Fprintf(&sb, `version: 2
-updates:
-  - package-ecosystem: "github-actions"
-    directories: ["/", ".github/actions/*/*.yml", ".github/actions/*/*.yaml", "action.yml", "action.yaml", "actions/*/*.yml", "actions/*/*.yaml"]
-    schedule:
-      interval: "%s"
-    groups:
-      github-actions:
-        patterns: ["*"]
-        update-types: ["minor", "patch"]
-    labels:
-      - "dependencies"
-`, interval)
+	config = PreserveManualEntries(config, opts.Existing, opts.ManualEntries)
+
+	if err := config.Validate(); err != nil {
+		return "", fmt.Errorf("generated config is invalid:\n%w", err)
+	}
+
+	content, err := config.Marshal()
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// BuildDependabotConfig returns a dependabot configuration with one update
+// entry per ecosystem detected by a scan, split further where the settings of
+// its directories differ, e.g. by grouping per directory, by code owners, by
+// per-directory settings or by the private registries they reference.
+func BuildDependabotConfig(result *ScanResult, opts Options) (*DependabotConfig, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	ecosystemDirs := result.EcosystemDirectories()
+
+	config := &DependabotConfig{Version: 2}
+	var registries map[string]Registry
+	var referencedFrom map[string][]string
+	if opts.PrivateRegistries {
+		registries, referencedFrom = privateRegistries(result.Registries)
+	}
 
 	// Sort ecosystems for deterministic output
 	var sortedEcosystems []string
@@ -65,26 +209,74 @@ updates:
 		}
 		sort.Strings(uniqueDirs)
 
-		fmt.Fprintf(&sb, `
-  - package-ecosystem: "%s"
-    directories: ["%s"]
-    schedule:
-      interval: "%s"
-    allow:
-      - dependency-type: all
-    groups:
-      %s:
-        patterns: ["*"]
-        update-types: ["minor", "patch"]
-    labels:
-      - "dependencies"
-`, eco, strings.Join(uniqueDirs, `", "`), interval, eco)
+		owners := func(dir string) []string {
+			if opts.CodeOwners == nil {
+				return nil
+			}
+			return result.owners(opts.CodeOwners, eco, dir)
+		}
+		// Directories share an update entry only if its settings and
+		// registries apply to all of them. Groups span the directories of an
+		// update entry, so grouping per directory takes an entry per
+		// directory.
+		type entryKey struct {
+			directory  string
+			registries []string
+			owners     []string
+			schedule   Schedule
+			grouping   Grouping
+			settings   EntrySettings
+		}
+		entries := partition(uniqueDirs, func(dir string) any {
+			key := entryKey{
+				registries: entryRegistries(registries, referencedFrom, eco, []string{dir}),
+				owners:     owners(dir),
+				schedule:   opts.scheduleFor(eco, dir),
+				grouping:   opts.groupingFor(eco, dir),
+				settings:   opts.settingsFor(eco, dir),
+			}
+			if key.grouping.perDirectory() {
+				key.directory = dir
+			}
+			return key
+		})
+		for _, dirs := range entries {
+			update := Update{
+				PackageEcosystem: eco,
+				Directories:      dirs,
+				Registries:       entryRegistries(registries, referencedFrom, eco, dirs),
+				Schedule:         opts.scheduleFor(eco, dirs[0]),
+				Groups:           opts.groupingFor(eco, dirs[0]).groups(eco, dirs[0]),
+			}
+			update.Reviewers, update.Assignees = reviewersAndAssignees(owners(dirs[0]), opts.CodeOwnersAs)
+			opts.settingsFor(eco, dirs[0]).apply(&update)
+			config.Updates = append(config.Updates, update)
+			for _, name := range update.Registries {
+				if config.Registries == nil {
+					config.Registries = make(map[string]Registry)
+				}
+				config.Registries[name] = registries[name]
+			}
+		}
 	}
 
-	if additionalYAML != "" {
-		sb.WriteString("\n")
-		sb.WriteString(additionalYAML)
-	}
+	return config, nil
+}
 
-	return sb.String(), nil
+// partition splits the directories into those with deeply equal keys, in
+// order of their first directory.
+func partition(dirs []string, key func(dir string) any) [][]string {
+	var keys []any
+	var partitions [][]string
+	for _, dir := range dirs {
+		k := key(dir)
+		i := slices.IndexFunc(keys, func(other any) bool { return reflect.DeepEqual(k, other) })
+		if i < 0 {
+			keys = append(keys, k)
+			partitions = append(partitions, nil)
+			i = len(keys) - 1
+		}
+		partitions[i] = append(partitions[i], dir)
+	}
+	return partitions
 }
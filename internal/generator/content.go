@@ -0,0 +1,138 @@
+package generator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log" //nolint:depguard // No need for slog just yet.
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ContentPredicate matches the content of the files in a directory matching
+// the File glob pattern. It holds if any such file matches Regex and contains
+// the Key path; at least one of the two must be given.
+type ContentPredicate struct {
+	File string `json:"file" yaml:"file"`
+	// Regex is matched against the raw file content.
+	Regex string `json:"regex,omitempty" yaml:"regex,omitempty"`
+	// Key is a dot-separated key path, e.g. "tool.poetry", which must exist
+	// in the parsed TOML, JSON or YAML file.
+	Key string `json:"key,omitempty" yaml:"key,omitempty"`
+}
+
+// validate returns an error if p cannot be checked.
+func (p ContentPredicate) validate() error {
+	if p.File == "" {
+		return errors.New("content predicate requires a file")
+	}
+	if p.Regex == "" && p.Key == "" {
+		return fmt.Errorf("content predicate for '%s' requires a regex or a key", p.File)
+	}
+	if p.Regex != "" {
+		if _, err := regexp.Compile(p.Regex); err != nil {
+			return fmt.Errorf("invalid regex '%s': %w", p.Regex, err)
+		}
+	}
+	return nil
+}
+
+// checkContent reports whether all Content predicates and none of the
+// AbsentContent predicates of rule hold, along with the files satisfying the
+// Content predicates.
+func checkContent(directory string, filesInDir []string, rule Heuristic) ([]string, bool, error) {
+	var files []string
+	for _, predicate := range rule.Content {
+		file, err := predicate.matchingFile(directory, filesInDir)
+		if err != nil || file == "" {
+			return nil, false, err
+		}
+		files = append(files, file)
+	}
+	for _, predicate := range rule.AbsentContent {
+		file, err := predicate.matchingFile(directory, filesInDir)
+		if err != nil || file != "" {
+			return nil, false, err
+		}
+	}
+	return files, true, nil
+}
+
+// matchingFile returns the first file matching p.File which satisfies the
+// predicate, or an empty string if there is none. The predicate must be
+// valid, see validate.
+func (p ContentPredicate) matchingFile(directory string, filesInDir []string) (string, error) {
+	var re *regexp.Regexp
+	if p.Regex != "" {
+		var err error
+		re, err = regexp.Compile(p.Regex)
+		if err != nil {
+			return "", fmt.Errorf("invalid regex '%s': %w", p.Regex, err)
+		}
+	}
+
+	files, err := matchingFiles(directory, filesInDir, p.File)
+	if err != nil {
+		return "", err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(directory, file))
+		if err != nil {
+			return "", fmt.Errorf("could not read %s: %w", file, err)
+		}
+		if re != nil && !re.Match(data) {
+			continue
+		}
+		if p.Key != "" {
+			found, err := hasKeyPath(file, data, p.Key)
+			if err != nil {
+				log.Printf("Warning: could not parse %s in %s: %v", file, directory, err)
+				continue
+			}
+			if !found {
+				continue
+			}
+		}
+		return file, nil
+	}
+	return "", nil
+}
+
+// hasKeyPath reports whether the dot-separated key path exists in data, which
+// is parsed according to the extension of file.
+func hasKeyPath(file string, data []byte, keyPath string) (bool, error) {
+	var document any
+	var err error
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".toml":
+		var table map[string]any
+		err = toml.Unmarshal(data, &table)
+		document = table
+	case ".json":
+		err = json.Unmarshal(data, &document)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &document)
+	default:
+		return false, fmt.Errorf("key paths are not supported for '%s'", file)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	current := document
+	for _, key := range strings.Split(keyPath, ".") {
+		table, ok := current.(map[string]any)
+		if !ok {
+			return false, nil
+		}
+		if current, ok = table[key]; !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
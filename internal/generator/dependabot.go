@@ -0,0 +1,251 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManualMarker is the comment which marks an update entry as manually managed.
+// Such entries are preserved by PreserveManualEntries.
+const ManualMarker = "dependabot-generate: manual"
+
+// DependabotConfig is a typed model of a dependabot.yml (version 2) file.
+//
+// Reference:
+// https://docs.github.com/en/code-security/dependabot/working-with-dependabot/dependabot-options-reference
+type DependabotConfig struct {
+	Version              int                            `yaml:"version"`
+	EnableBetaEcosystems bool                           `yaml:"enable-beta-ecosystems,omitempty"`
+	Registries           map[string]Registry            `yaml:"registries,omitempty"`
+	MultiEcosystemGroups map[string]MultiEcosystemGroup `yaml:"multi-ecosystem-groups,omitempty"`
+	Updates              []Update                       `yaml:"updates"`
+}
+
+// Registry is a private registry which update entries can reference by name.
+type Registry struct {
+	Type                 string `yaml:"type"`
+	URL                  string `yaml:"url,omitempty"`
+	Username             string `yaml:"username,omitempty"`
+	Password             string `yaml:"password,omitempty"`
+	Key                  string `yaml:"key,omitempty"`
+	Token                string `yaml:"token,omitempty"`
+	ReplacesBase         bool   `yaml:"replaces-base,omitempty"`
+	Organization         string `yaml:"organization,omitempty"`
+	Repo                 string `yaml:"repo,omitempty"`
+	AuthKey              string `yaml:"auth-key,omitempty"`
+	PublicKeyFingerprint string `yaml:"public-key-fingerprint,omitempty"`
+}
+
+// Update is a single entry of the `updates` list. Entries of a multi-ecosystem
+// group select their dependencies with Patterns and may leave out the
+// Schedule, which the group provides.
+type Update struct {
+	PackageEcosystem              string                 `yaml:"package-ecosystem"`
+	Directory                     string                 `yaml:"directory,omitempty"`
+	Directories                   []string               `yaml:"directories,omitempty"`
+	ExcludePaths                  []string               `yaml:"exclude-paths,omitempty"`
+	TargetBranch                  string                 `yaml:"target-branch,omitempty"`
+	Registries                    Registries             `yaml:"registries,omitempty"`
+	Schedule                      Schedule               `yaml:"schedule,omitempty"`
+	Cooldown                      *Cooldown              `yaml:"cooldown,omitempty"`
+	Allow                         []Allow                `yaml:"allow,omitempty"`
+	Ignore                        []Ignore               `yaml:"ignore,omitempty"`
+	Groups                        map[string]Group       `yaml:"groups,omitempty"`
+	Labels                        []string               `yaml:"labels,omitempty"`
+	Assignees                     []string               `yaml:"assignees,omitempty"`
+	Reviewers                     []string               `yaml:"reviewers,omitempty"`
+	Milestone                     int                    `yaml:"milestone,omitempty"`
+	CommitMessage                 *CommitMessage         `yaml:"commit-message,omitempty"`
+	OpenPullRequestsLimit         *int                   `yaml:"open-pull-requests-limit,omitempty"`
+	PullRequestBranchName         *PullRequestBranchName `yaml:"pull-request-branch-name,omitempty"`
+	RebaseStrategy                string                 `yaml:"rebase-strategy,omitempty"`
+	InsecureExternalCodeExecution string                 `yaml:"insecure-external-code-execution,omitempty"`
+	Vendor                        bool                   `yaml:"vendor,omitempty"`
+	VersioningStrategy            string                 `yaml:"versioning-strategy,omitempty"`
+	MultiEcosystemGroup           string                 `yaml:"multi-ecosystem-group,omitempty"`
+	Patterns                      []string               `yaml:"patterns,omitempty"`
+
+	// Manual marks the entry as manually managed. It is rendered as a
+	// ManualMarker comment above the entry.
+	Manual bool `yaml:"-"`
+}
+
+// Schedule defines when Dependabot checks for new versions.
+type Schedule struct {
+	Interval string `yaml:"interval"`
+	Day      string `yaml:"day,omitempty"`
+	Time     string `yaml:"time,omitempty"`
+	Timezone string `yaml:"timezone,omitempty"`
+	Cronjob  string `yaml:"cronjob,omitempty"`
+}
+
+// MultiEcosystemGroup combines the updates of the entries referencing it, across
+// ecosystems, into a single pull request.
+type MultiEcosystemGroup struct {
+	Schedule              Schedule               `yaml:"schedule"`
+	TargetBranch          string                 `yaml:"target-branch,omitempty"`
+	Labels                []string               `yaml:"labels,omitempty"`
+	Assignees             []string               `yaml:"assignees,omitempty"`
+	Milestone             int                    `yaml:"milestone,omitempty"`
+	CommitMessage         *CommitMessage         `yaml:"commit-message,omitempty"`
+	PullRequestBranchName *PullRequestBranchName `yaml:"pull-request-branch-name,omitempty"`
+}
+
+// Cooldown delays version updates until a release has aged for some days.
+type Cooldown struct {
+	DefaultDays     int      `yaml:"default-days,omitempty"`
+	SemverMajorDays int      `yaml:"semver-major-days,omitempty"`
+	SemverMinorDays int      `yaml:"semver-minor-days,omitempty"`
+	SemverPatchDays int      `yaml:"semver-patch-days,omitempty"`
+	Include         []string `yaml:"include,omitempty"`
+	Exclude         []string `yaml:"exclude,omitempty"`
+}
+
+// Allow restricts which dependencies are updated.
+type Allow struct {
+	DependencyName string `yaml:"dependency-name,omitempty"`
+	DependencyType string `yaml:"dependency-type,omitempty"`
+}
+
+// Ignore excludes dependencies, versions or update types from updates.
+type Ignore struct {
+	DependencyName string   `yaml:"dependency-name"`
+	Versions       []string `yaml:"versions,omitempty"`
+	UpdateTypes    []string `yaml:"update-types,omitempty"`
+}
+
+// Group bundles several dependency updates into a single pull request.
+type Group struct {
+	AppliesTo       string   `yaml:"applies-to,omitempty"`
+	DependencyType  string   `yaml:"dependency-type,omitempty"`
+	Patterns        []string `yaml:"patterns,omitempty"`
+	ExcludePatterns []string `yaml:"exclude-patterns,omitempty"`
+	UpdateTypes     []string `yaml:"update-types,omitempty"`
+	GroupBy         string   `yaml:"group-by,omitempty"`
+}
+
+// CommitMessage configures the commit messages of Dependabot pull requests.
+type CommitMessage struct {
+	Prefix            string `yaml:"prefix,omitempty"`
+	PrefixDevelopment string `yaml:"prefix-development,omitempty"`
+	Include           string `yaml:"include,omitempty"`
+}
+
+// PullRequestBranchName configures the branch names of Dependabot pull requests.
+type PullRequestBranchName struct {
+	Separator string `yaml:"separator"`
+}
+
+// Registries lists the registry names an update entry may use. Dependabot
+// also accepts the scalar "*" to allow all registries.
+type Registries []string
+
+// UnmarshalYAML accepts either a single registry name or a list of names.
+func (r *Registries) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*r = Registries{value.Value}
+		return nil
+	}
+	var names []string
+	if err := value.Decode(&names); err != nil {
+		return err
+	}
+	*r = names
+	return nil
+}
+
+// MarshalYAML renders the "*" wildcard as a scalar, as Dependabot expects.
+func (r Registries) MarshalYAML() (any, error) {
+	if len(r) == 1 && r[0] == "*" {
+		return "*", nil
+	}
+	return []string(r), nil
+}
+
+// Marshal renders the configuration as YAML.
+func (c *DependabotConfig) Marshal() ([]byte, error) {
+	var node yaml.Node
+	if err := node.Encode(c); err != nil {
+		return nil, fmt.Errorf("failed to marshal dependabot config: %w", err)
+	}
+	if updates := updatesNode(&node); updates != nil {
+		for i, update := range c.Updates {
+			if update.Manual && i < len(updates.Content) {
+				updates.Content[i].HeadComment = "# " + ManualMarker
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return nil, fmt.Errorf("failed to marshal dependabot config: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to marshal dependabot config: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ParseDependabotConfig parses a dependabot.yml file. Unknown keys are
+// reported as errors. Entries preceded by a ManualMarker comment are marked as
+// manually managed.
+func ParseDependabotConfig(data []byte) (*DependabotConfig, error) {
+	var config DependabotConfig
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse dependabot config: %w", err)
+	}
+
+	// Comments are only available on the node tree.
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("failed to parse dependabot config: %w", err)
+	}
+	if updates := updatesNode(&node); updates != nil {
+		for i, item := range updates.Content {
+			if i < len(config.Updates) && isManual(item) {
+				config.Updates[i].Manual = true
+			}
+		}
+	}
+	return &config, nil
+}
+
+// updatesNode returns the sequence node of the top-level `updates` key, if any.
+func updatesNode(document *yaml.Node) *yaml.Node {
+	root := document
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "updates" && root.Content[i+1].Kind == yaml.SequenceNode {
+			return root.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// isManual reports whether an update entry node carries the ManualMarker comment.
+func isManual(item *yaml.Node) bool {
+	comments := []string{item.HeadComment}
+	if len(item.Content) > 0 {
+		comments = append(comments, item.Content[0].HeadComment)
+	}
+	for _, comment := range comments {
+		if strings.Contains(comment, ManualMarker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// excludePattern is a single exclude-paths entry.
+type excludePattern struct {
+	glob   string
+	negate bool
+}
+
+// excludeMatcher decides which paths are excluded from the scan. Patterns are
+// doublestar globs, anchored at the scan root, e.g. "examples/**" or
+// "**/testdata". A leading "!" negates a pattern, carving an exception out of
+// earlier patterns.
+type excludeMatcher struct {
+	patterns []excludePattern
+}
+
+// newExcludeMatcher parses the exclude-paths patterns.
+func newExcludeMatcher(patterns []string) (*excludeMatcher, error) {
+	m := &excludeMatcher{}
+	for _, raw := range patterns {
+		pattern := strings.TrimSpace(raw)
+		negate := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "./")
+		pattern = strings.Trim(pattern, "/")
+		if pattern == "" {
+			continue
+		}
+		if !doublestar.ValidatePattern(pattern) {
+			return nil, fmt.Errorf("invalid exclude pattern '%s'", raw)
+		}
+		m.patterns = append(m.patterns, excludePattern{glob: pattern, negate: negate})
+	}
+	return m, nil
+}
+
+// excluded reports whether relPath, a slash-separated path relative to the
+// scan root, is excluded, along with the deciding pattern. A pattern applies
+// if it matches the path or one of its parent directories; the last pattern
+// that applies decides.
+func (m *excludeMatcher) excluded(relPath string) (bool, string) {
+	if relPath == "." || relPath == "" {
+		return false, ""
+	}
+
+	var candidates []string
+	for dir := relPath; dir != "."; dir = path.Dir(dir) {
+		candidates = append(candidates, dir)
+	}
+
+	excluded, decidedBy := false, ""
+	for _, pattern := range m.patterns {
+		for _, candidate := range candidates {
+			if doublestar.MatchUnvalidated(pattern.glob, candidate) {
+				excluded = !pattern.negate
+				decidedBy = pattern.glob
+				if pattern.negate {
+					decidedBy = "!" + decidedBy
+				}
+				break
+			}
+		}
+	}
+	return excluded, decidedBy
+}
+
+// mayIncludeBelow reports whether a negated pattern could re-include a path
+// below the excluded directory relPath, in which case the directory must
+// still be walked.
+func (m *excludeMatcher) mayIncludeBelow(relPath string) bool {
+	for _, pattern := range m.patterns {
+		if !pattern.negate {
+			continue
+		}
+		base := staticPrefix(pattern.glob)
+		if base == "" || isPathWithin(base, relPath) || isPathWithin(relPath, base) {
+			return true
+		}
+	}
+	return false
+}
+
+// staticPrefix returns the leading path segments of glob without any glob
+// meta characters.
+func staticPrefix(glob string) string {
+	var segments []string
+	for _, segment := range strings.Split(glob, "/") {
+		if strings.ContainsAny(segment, `*?[{\`) {
+			break
+		}
+		segments = append(segments, segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// isPathWithin reports whether the slash-separated path p equals dir or lies below it.
+func isPathWithin(p, dir string) bool {
+	return p == dir || strings.HasPrefix(p, dir+"/")
+}
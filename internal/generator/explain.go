@@ -0,0 +1,160 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EntryEvaluation is the outcome of evaluating an ecosystem map entry in a
+// directory.
+type EntryEvaluation struct {
+	Ecosystem string
+	// Rules holds the heuristics of the entry, in order, followed by its
+	// patterns, if any.
+	Rules []RuleEvaluation
+	// Winner is the rule which detected the ecosystem, empty if none did.
+	Winner string
+	// Shadowed is set if the ecosystem was already detected by an earlier
+	// entry, in which case the entry does not take part in the detection.
+	Shadowed bool
+}
+
+// RuleEvaluation is the outcome of evaluating a single rule of an ecosystem
+// map entry, either "heuristics[i]" or "patterns".
+type RuleEvaluation struct {
+	Rule    string
+	Matched bool
+	Checks  []CheckEvaluation
+}
+
+// CheckEvaluation is the outcome of a single condition of a rule.
+type CheckEvaluation struct {
+	// Kind is one of "present", "absent", "content", "absent-content" or
+	// "pattern".
+	Kind string
+	// Pattern is the glob pattern or, for content predicates, a description
+	// of the predicate.
+	Pattern string
+	// Files are the files matching the pattern or satisfying the predicate.
+	Files []string
+	// Satisfied reports whether the condition holds, e.g. that no files match
+	// an "absent" pattern.
+	Satisfied bool
+}
+
+// ExplainDirectory evaluates every rule of the ecosystem map in a directory,
+// without stopping at the first match, and reports which rule detected each
+// ecosystem the way DetectEcosystems does.
+func ExplainDirectory(directory string, ecosystemMap []EcosystemMapEntry) ([]EntryEvaluation, error) {
+	filesInDir, err := getFilesInDir(directory)
+	if err != nil {
+		return nil, fmt.Errorf("could not read directory %s: %w", directory, err)
+	}
+
+	detected := make(map[string]struct{})
+	evaluations := make([]EntryEvaluation, 0, len(ecosystemMap))
+	for _, entry := range ecosystemMap {
+		evaluation := EntryEvaluation{Ecosystem: entry.Ecosystem}
+		if _, ok := detected[entry.Ecosystem]; ok {
+			evaluation.Shadowed = true
+		}
+
+		for i, heuristic := range entry.Heuristics {
+			rule, err := evaluateHeuristic(directory, filesInDir, heuristic)
+			if err != nil {
+				return nil, err
+			}
+			rule.Rule = fmt.Sprintf("heuristics[%d]", i)
+			if rule.Matched && !evaluation.Shadowed && evaluation.Winner == "" {
+				evaluation.Winner = rule.Rule
+			}
+			evaluation.Rules = append(evaluation.Rules, rule)
+		}
+
+		if len(entry.Patterns) > 0 {
+			rule := RuleEvaluation{Rule: "patterns"}
+			for _, pattern := range entry.Patterns {
+				files, err := matchingFiles(directory, filesInDir, pattern)
+				if err != nil {
+					return nil, err
+				}
+				rule.Checks = append(rule.Checks, CheckEvaluation{
+					Kind: "pattern", Pattern: pattern, Files: files, Satisfied: len(files) > 0,
+				})
+				rule.Matched = rule.Matched || len(files) > 0
+			}
+			if rule.Matched && !evaluation.Shadowed && evaluation.Winner == "" {
+				evaluation.Winner = rule.Rule
+			}
+			evaluation.Rules = append(evaluation.Rules, rule)
+		}
+
+		if evaluation.Winner != "" {
+			detected[entry.Ecosystem] = struct{}{}
+		}
+		evaluations = append(evaluations, evaluation)
+	}
+	return evaluations, nil
+}
+
+// evaluateHeuristic evaluates every condition of a heuristic rule.
+func evaluateHeuristic(directory string, filesInDir []string, heuristic Heuristic) (RuleEvaluation, error) {
+	var rule RuleEvaluation
+	for _, kind := range []struct {
+		name     string
+		patterns []string
+		present  bool
+	}{
+		{"present", heuristic.Present, true},
+		{"absent", heuristic.Absent, false},
+	} {
+		for _, pattern := range kind.patterns {
+			files, err := matchingFiles(directory, filesInDir, pattern)
+			if err != nil {
+				return rule, err
+			}
+			rule.Checks = append(rule.Checks, CheckEvaluation{
+				Kind: kind.name, Pattern: pattern, Files: files, Satisfied: (len(files) > 0) == kind.present,
+			})
+		}
+	}
+
+	for _, kind := range []struct {
+		name       string
+		predicates []ContentPredicate
+		present    bool
+	}{
+		{"content", heuristic.Content, true},
+		{"absent-content", heuristic.AbsentContent, false},
+	} {
+		for _, predicate := range kind.predicates {
+			file, err := predicate.matchingFile(directory, filesInDir)
+			if err != nil {
+				return rule, err
+			}
+			check := CheckEvaluation{Kind: kind.name, Pattern: predicate.String(), Satisfied: (file != "") == kind.present}
+			if file != "" {
+				check.Files = []string{file}
+			}
+			rule.Checks = append(rule.Checks, check)
+		}
+	}
+
+	rule.Matched = true
+	for _, check := range rule.Checks {
+		rule.Matched = rule.Matched && check.Satisfied
+	}
+	return rule, nil
+}
+
+// String describes the predicate, e.g. "pyproject.toml has key tool.poetry".
+func (p ContentPredicate) String() string {
+	var conditions []string
+	if p.Regex != "" {
+		conditions = append(conditions, fmt.Sprintf("matches /%s/", p.Regex))
+	}
+	if p.Key != "" {
+		conditions = append(conditions, "has key "+p.Key)
+	}
+	return p.File + " " + strings.Join(conditions, " and ")
+}
@@ -2,14 +2,34 @@ package generator_test
 
 import (
 	"os"
+	"os/exec"
+	"path"
 	"path/filepath"
 	"reflect"
 	"sort"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/fredrikaverpil/dependabot-generate/internal/generator"
+	"gopkg.in/yaml.v3"
 )
 
+// writeFiles writes the files, keyed by their path relative to root, creating
+// their parent directories.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write file %s: %v", name, err)
+		}
+	}
+}
+
 func TestGetEcosystemMap(t *testing.T) {
 	t.Parallel()
 	// 1. Test with no custom map
@@ -57,18 +77,6 @@ func TestGetEcosystemMap(t *testing.T) {
 
 func TestDetectPackageEcosystems(t *testing.T) {
 	t.Parallel()
-	// Helper to create a temporary directory with files
-	createTempDirWithFiles := func(t *testing.T, files map[string]string) string {
-		t.Helper()
-		dir := t.TempDir()
-		for name, content := range files {
-			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
-				t.Fatalf("Failed to write file %s: %v", name, err)
-			}
-		}
-		return dir
-	}
-
 	ecosystemMap, _ := generator.GetEcosystemMap("") // Use default map for tests
 
 	testCases := []struct {
@@ -96,6 +104,21 @@ func TestDetectPackageEcosystems(t *testing.T) {
 			files:              map[string]string{"go.mod": "", "Dockerfile": ""},
 			expectedEcosystems: []string{"docker", "gomod"},
 		},
+		{
+			name:               "workflows",
+			files:              map[string]string{".github/workflows/ci.yml": "on: push"},
+			expectedEcosystems: []string{"github-actions"},
+		},
+		{
+			name:               "composite action",
+			files:              map[string]string{"action.yaml": "runs:\n  using: composite"},
+			expectedEcosystems: []string{"github-actions"},
+		},
+		{
+			name:               "workflows directory without workflows",
+			files:              map[string]string{".github/workflows/README.md": ""},
+			expectedEcosystems: []string{},
+		},
 		{
 			name:               "no match",
 			files:              map[string]string{"README.md": "# My Project"},
@@ -106,7 +129,8 @@ func TestDetectPackageEcosystems(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
-			dir := createTempDirWithFiles(t, tc.files)
+			dir := t.TempDir()
+			writeFiles(t, dir, tc.files)
 			detected, err := generator.DetectPackageEcosystems(dir, ecosystemMap)
 			if err != nil {
 				t.Fatalf("Expected no error, but got %v", err)
@@ -127,53 +151,482 @@ func TestDetectPackageEcosystems(t *testing.T) {
 	}
 }
 
+func TestDetectEcosystems(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"pyproject.toml":           "[tool.poetry]\n",
+		"poetry.lock":              "",
+		"Dockerfile":               "FROM scratch",
+		"action.yml":               "runs:\n  using: composite",
+		".github/workflows/ci.yml": "on: push",
+	})
+
+	ecosystemMap, _ := generator.GetEcosystemMap("")
+	detections, err := generator.DetectEcosystems(dir, ecosystemMap)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	expected := []generator.Detection{
+		{Ecosystem: "docker", Rule: "patterns", Files: []string{"Dockerfile"}},
+		{Ecosystem: "github-actions", Rule: "patterns", Files: []string{".github/workflows/ci.yml", "action.yml"}},
+		{Ecosystem: "pip", Rule: "heuristics[1]", Files: []string{"poetry.lock", "pyproject.toml"}},
+	}
+	if !reflect.DeepEqual(detections, expected) {
+		t.Errorf("Expected detections %+v, but got %+v", expected, detections)
+	}
+}
+
+func TestDetectPackageEcosystemsContent(t *testing.T) {
+	t.Parallel()
+
+	ecosystemMap, err := generator.ParseEcosystemMap(`[
+		{"ecosystem": "pip", "heuristics": [
+			{"present": ["pyproject.toml"], "content": [{"file": "pyproject.toml", "key": "tool.poetry"}]}
+		]},
+		{"ecosystem": "uv", "heuristics": [
+			{"present": ["pyproject.toml"], "absent-content": [{"file": "pyproject.toml", "key": "tool.poetry"}]}
+		]},
+		{"ecosystem": "npm", "heuristics": [
+			{"present": ["package.json"], "absent-content": [{"file": "package.json", "regex": "\"private\":\\s*true"}]}
+		]},
+		{"ecosystem": "docker", "heuristics": [
+			{"present": ["compose.yaml"], "content": [{"file": "compose.yaml", "key": "services.web.build"}]}
+		]}
+	]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name               string
+		files              map[string]string
+		expectedEcosystems []string
+	}{
+		{
+			name:               "toml key present",
+			files:              map[string]string{"pyproject.toml": "[tool.poetry]\nname = \"x\"\n"},
+			expectedEcosystems: []string{"pip"},
+		},
+		{
+			name:               "toml key absent",
+			files:              map[string]string{"pyproject.toml": "[project]\nname = \"x\"\n"},
+			expectedEcosystems: []string{"uv"},
+		},
+		{
+			name:               "regex absent",
+			files:              map[string]string{"package.json": `{"name": "x"}`},
+			expectedEcosystems: []string{"npm"},
+		},
+		{
+			name:               "regex present",
+			files:              map[string]string{"package.json": `{"name": "x", "private": true}`},
+			expectedEcosystems: nil,
+		},
+		{
+			name:               "yaml key present",
+			files:              map[string]string{"compose.yaml": "services:\n  web:\n    build: .\n"},
+			expectedEcosystems: []string{"docker"},
+		},
+		{
+			name:               "yaml key below a scalar",
+			files:              map[string]string{"compose.yaml": "services:\n  web: nginx\n"},
+			expectedEcosystems: nil,
+		},
+		{
+			name:               "unparsable file",
+			files:              map[string]string{"compose.yaml": "services: [\n"},
+			expectedEcosystems: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			writeFiles(t, dir, tc.files)
+
+			detected, err := generator.DetectPackageEcosystems(dir, ecosystemMap)
+			if err != nil {
+				t.Fatalf("Expected no error, but got %v", err)
+			}
+			if !reflect.DeepEqual(detected, tc.expectedEcosystems) {
+				t.Errorf("Expected ecosystems %v, but got %v", tc.expectedEcosystems, detected)
+			}
+		})
+	}
+
+	t.Run("invalid regex", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte("{}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		invalidMap := []generator.EcosystemMapEntry{{
+			Ecosystem: "npm",
+			Heuristics: []generator.Heuristic{{
+				Present: []string{"package.json"},
+				Content: []generator.ContentPredicate{{File: "package.json", Regex: "("}},
+			}},
+		}}
+		if _, err := generator.DetectPackageEcosystems(dir, invalidMap); err == nil {
+			t.Fatal("Expected an error for an invalid regex, but got nil")
+		}
+	})
+
+	t.Run("invalid predicates fail parsing", func(t *testing.T) {
+		t.Parallel()
+		for _, predicate := range []string{
+			`{"file": "package.json", "regex": "("}`,
+			`{"regex": "private"}`,
+			`{"file": "package.json"}`,
+		} {
+			customMap := `[{"ecosystem": "npm", "heuristics": [{"present": ["package.json"], "absent-content": [` +
+				predicate + `]}]}]`
+			if _, err := generator.ParseEcosystemMap(customMap); err == nil {
+				t.Errorf("Expected an error parsing the predicate %s, but got nil", predicate)
+			}
+		}
+
+		invalidMap := []generator.EcosystemMapEntry{{
+			Ecosystem:  "npm",
+			Heuristics: []generator.Heuristic{{Content: []generator.ContentPredicate{{File: "package.json", Regex: "("}}}},
+		}}
+		if _, err := generator.ExtendEcosystemMap(invalidMap); err == nil {
+			t.Error("Expected an error extending the map with an invalid regex, but got nil")
+		}
+	})
+}
+
+func TestExplainDirectory(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"pyproject.toml": "", "requirements.txt": "", "go.mod": ""})
+
+	ecosystemMap, err := generator.GetEcosystemMap(`[{"ecosystem": "gomod", "patterns": ["go.*"]}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	evaluations, err := generator.ExplainDirectory(dir, ecosystemMap)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	winners := make(map[string]string)
+	var shadowed []string
+	for _, evaluation := range evaluations {
+		if evaluation.Winner != "" {
+			winners[evaluation.Ecosystem] = evaluation.Winner
+		}
+		if evaluation.Shadowed {
+			shadowed = append(shadowed, evaluation.Ecosystem)
+		}
+		if evaluation.Ecosystem == "pip" && len(evaluation.Rules) != 7 {
+			t.Errorf("Expected all 7 pip heuristics to be evaluated, but got %d", len(evaluation.Rules))
+		}
+	}
+
+	expected := map[string]string{"gomod": "patterns", "pip": "heuristics[4]"}
+	if !reflect.DeepEqual(winners, expected) {
+		t.Errorf("Expected winners %v, but got %v", expected, winners)
+	}
+	if !reflect.DeepEqual(shadowed, []string{"gomod"}) {
+		t.Errorf("Expected the default gomod entry to be shadowed, but got %v", shadowed)
+	}
+
+	detected, err := generator.DetectPackageEcosystems(dir, ecosystemMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(detected, []string{"gomod", "pip"}) {
+		t.Errorf("Expected explanation to agree with detection, but detected %v", detected)
+	}
+}
+
 func TestGenerateDependabotConfig(t *testing.T) {
 	t.Parallel()
+	ten, zero := 10, 0
 	testCases := []struct {
 		name        string
-		directories []string
-		files       map[string]string
+		directories []generator.Directory
+		opts        generator.Options
 		goldenFile  string
 	}{
 		{
-			name:        "single project",
-			directories: []string{"."},
-			files: map[string]string{
-				"go.mod":     "module my-project",
-				"Dockerfile": "FROM golang",
+			name: "single project",
+			directories: []generator.Directory{
+				{Path: ".", Detections: []generator.Detection{
+					{Ecosystem: "docker", Files: []string{"Dockerfile"}},
+					{Ecosystem: "gomod", Files: []string{"go.mod"}},
+				}},
 			},
+			opts:       generator.Options{Schedule: generator.Schedule{Interval: "daily"}},
 			goldenFile: "single_project.golden.yml",
 		},
 		{
-			name:        "monorepo",
-			directories: []string{".", "yolo"},
-			files: map[string]string{
-				"go.mod":      "module my-project",
-				"Dockerfile":  "FROM golang",
-				"yolo/go.mod": "module yolo",
+			name: "monorepo",
+			directories: []generator.Directory{
+				{Path: ".", Detections: []generator.Detection{
+					{Ecosystem: "docker", Files: []string{"Dockerfile"}},
+					{Ecosystem: "github-actions", Files: []string{"action.yml"}},
+					{Ecosystem: "gomod", Files: []string{"go.mod"}},
+				}},
+				{Path: "yolo", Detections: []generator.Detection{
+					{Ecosystem: "gomod", Files: []string{"go.mod"}},
+				}},
+				{Path: "yolo/action", Detections: []generator.Detection{
+					{Ecosystem: "github-actions", Files: []string{"action.yml"}},
+				}},
 			},
+			opts:       generator.Options{Schedule: generator.Schedule{Interval: "daily"}},
 			goldenFile: "monorepo.golden.yml",
 		},
+		{
+			name: "per-ecosystem schedules",
+			directories: []generator.Directory{
+				{Path: ".", Detections: []generator.Detection{
+					{Ecosystem: "docker", Files: []string{"Dockerfile"}},
+					{Ecosystem: "gomod", Files: []string{"go.mod"}},
+				}},
+				{Path: "infra", Detections: []generator.Detection{
+					{Ecosystem: "terraform", Files: []string{"main.tf"}},
+				}},
+			},
+			opts: generator.Options{
+				Schedule: generator.Schedule{Interval: "weekly"},
+				Ecosystems: map[string]generator.EcosystemOptions{
+					"gomod": {Schedule: &generator.Schedule{Interval: "daily"}},
+					"docker": {Schedule: &generator.Schedule{
+						Interval: "weekly",
+						Day:      "monday",
+						Time:     "06:00",
+						Timezone: "Europe/Stockholm",
+					}},
+					"terraform": {Schedule: &generator.Schedule{Interval: "cron", Cronjob: "0 6 1 * *"}},
+				},
+			},
+			goldenFile: "schedules.golden.yml",
+		},
+		{
+			name: "grouping strategies",
+			directories: []generator.Directory{
+				{Path: ".", Detections: []generator.Detection{
+					{Ecosystem: "docker", Files: []string{"Dockerfile"}},
+					{Ecosystem: "gomod", Files: []string{"go.mod"}},
+					{Ecosystem: "npm", Files: []string{"package.json"}},
+				}},
+				{Path: "infra", Detections: []generator.Detection{
+					{Ecosystem: "terraform", Files: []string{"main.tf"}},
+				}},
+				{Path: "tools", Detections: []generator.Detection{
+					{Ecosystem: "gomod", Files: []string{"go.mod"}},
+				}},
+				{Path: "web/portal", Detections: []generator.Detection{
+					{Ecosystem: "npm", Files: []string{"package.json"}},
+				}},
+			},
+			opts: generator.Options{
+				Schedule: generator.Schedule{Interval: "weekly"},
+				Grouping: generator.Grouping{Strategy: generator.GroupByDirectory},
+				Ecosystems: map[string]generator.EcosystemOptions{
+					"docker": {Grouping: &generator.Grouping{Strategy: generator.GroupNone}},
+					"npm": {Grouping: &generator.Grouping{
+						Strategy: generator.GroupByDependencyType,
+						Groups: map[string]generator.Group{
+							"aws-sdk": {Patterns: []string{"@aws-sdk/*"}},
+						},
+					}},
+					"terraform": {Grouping: &generator.Grouping{Strategy: generator.GroupAll}},
+				},
+			},
+			goldenFile: "grouping.golden.yml",
+		},
+		{
+			name: "security update grouping",
+			directories: []generator.Directory{
+				{Path: ".", Detections: []generator.Detection{
+					{Ecosystem: "gomod", Files: []string{"go.mod"}},
+					{Ecosystem: "npm", Files: []string{"package.json"}},
+				}},
+				{Path: "tools", Detections: []generator.Detection{
+					{Ecosystem: "gomod", Files: []string{"go.mod"}},
+				}},
+			},
+			opts: generator.Options{
+				Schedule: generator.Schedule{Interval: "weekly"},
+				Grouping: generator.Grouping{
+					SecurityUpdates: &generator.Grouping{Strategy: generator.GroupAll},
+				},
+				Ecosystems: map[string]generator.EcosystemOptions{
+					"npm": {Grouping: &generator.Grouping{
+						Strategy: generator.GroupNone,
+						SecurityUpdates: &generator.Grouping{
+							Strategy: generator.GroupByDirectory,
+							Groups: map[string]generator.Group{
+								"aws-sdk": {Patterns: []string{"@aws-sdk/*"}},
+							},
+						},
+					}},
+				},
+			},
+			goldenFile: "security_grouping.golden.yml",
+		},
+		{
+			name: "code owners",
+			directories: []generator.Directory{
+				{Path: ".", Detections: []generator.Detection{
+					{Ecosystem: "gomod", Files: []string{"go.mod"}},
+					{Ecosystem: "npm", Files: []string{"package.json"}},
+				}},
+				{Path: "services/api", Detections: []generator.Detection{
+					{Ecosystem: "gomod", Files: []string{"go.mod"}},
+				}},
+				{Path: "services/batch", Detections: []generator.Detection{
+					{Ecosystem: "gomod", Files: []string{"go.mod"}},
+				}},
+				{Path: "web", Detections: []generator.Detection{
+					{Ecosystem: "npm", Files: []string{"package.json"}},
+				}},
+			},
+			opts: generator.Options{
+				Schedule: generator.Schedule{Interval: "weekly"},
+				CodeOwners: generator.ParseCodeOwners([]byte(
+					"* @acme/platform\n/services/ @acme/backend @alice\n/web/ @acme/frontend dev@example.com\n",
+				)),
+				CodeOwnersAs: generator.CodeOwnersAsBoth,
+			},
+			goldenFile: "codeowners.golden.yml",
+		},
+		{
+			name: "pull request settings",
+			directories: []generator.Directory{
+				{Path: ".", Detections: []generator.Detection{
+					{Ecosystem: "docker", Files: []string{"Dockerfile"}},
+					{Ecosystem: "gomod", Files: []string{"go.mod"}},
+				}},
+				{Path: "services/api", Detections: []generator.Detection{
+					{Ecosystem: "gomod", Files: []string{"go.mod"}},
+				}},
+				{Path: "services/legacy", Detections: []generator.Detection{
+					{Ecosystem: "gomod", Files: []string{"go.mod"}},
+				}},
+				{Path: "tools", Detections: []generator.Detection{
+					{Ecosystem: "gomod", Files: []string{"go.mod"}},
+				}},
+			},
+			opts: generator.Options{
+				Schedule: generator.Schedule{Interval: "weekly"},
+				Settings: generator.EntrySettings{
+					CommitMessage:         &generator.CommitMessage{Prefix: "chore(deps)", Include: "scope"},
+					OpenPullRequestsLimit: &ten,
+					PullRequestBranchName: &generator.PullRequestBranchName{Separator: "-"},
+				},
+				Ecosystems: map[string]generator.EcosystemOptions{
+					"gomod": {EntrySettings: generator.EntrySettings{
+						Labels:        []string{"dependencies", "go"},
+						CommitMessage: &generator.CommitMessage{Prefix: "build(deps)"},
+					}},
+					"docker": {EntrySettings: generator.EntrySettings{Labels: []string{}, Milestone: 4}},
+				},
+				Directories: map[string]generator.EntrySettings{
+					"/services/legacy/": {OpenPullRequestsLimit: &zero, RebaseStrategy: "disabled"},
+					"tools":             {CommitMessage: &generator.CommitMessage{PrefixDevelopment: "chore(tools)"}},
+				},
+			},
+			goldenFile: "settings.golden.yml",
+		},
+		{
+			name: "ignore rules and allow-lists",
+			directories: []generator.Directory{
+				{Path: ".", Detections: []generator.Detection{
+					{Ecosystem: "docker", Files: []string{"Dockerfile"}},
+					{Ecosystem: "gomod", Files: []string{"go.mod"}},
+					{Ecosystem: "npm", Files: []string{"package.json"}},
+				}},
+				{Path: "services/legacy", Detections: []generator.Detection{
+					{Ecosystem: "gomod", Files: []string{"go.mod"}},
+				}},
+			},
+			opts: generator.Options{
+				Schedule: generator.Schedule{Interval: "weekly"},
+				Settings: generator.EntrySettings{
+					Ignore: []generator.Ignore{
+						{DependencyName: "k8s.io/*", UpdateTypes: []string{"version-update:semver-major"}},
+					},
+				},
+				Ecosystems: map[string]generator.EcosystemOptions{
+					"docker": {EntrySettings: generator.EntrySettings{
+						Ignore: []generator.Ignore{{DependencyName: "node", Versions: []string{">=23"}}},
+					}},
+					"npm": {EntrySettings: generator.EntrySettings{
+						Allow: []generator.Allow{{DependencyType: "production"}},
+					}},
+				},
+				Directories: map[string]generator.EntrySettings{
+					"services/legacy": {Ignore: []generator.Ignore{
+						{DependencyName: "k8s.io/*", UpdateTypes: []string{"version-update:semver-major"}},
+						{DependencyName: "*", UpdateTypes: []string{"version-update:semver-major"}},
+					}},
+				},
+			},
+			goldenFile: "ignore.golden.yml",
+		},
+		{
+			name: "path rules",
+			directories: []generator.Directory{
+				{Path: ".", Detections: []generator.Detection{
+					{Ecosystem: "gomod", Files: []string{"go.mod"}},
+					{Ecosystem: "npm", Files: []string{"package.json"}},
+				}},
+				{Path: "services/orders", Detections: []generator.Detection{
+					{Ecosystem: "gomod", Files: []string{"go.mod"}},
+				}},
+				{Path: "services/payments", Detections: []generator.Detection{
+					{Ecosystem: "gomod", Files: []string{"go.mod"}},
+					{Ecosystem: "npm", Files: []string{"package.json"}},
+				}},
+				{Path: "services/payments/ui", Detections: []generator.Detection{
+					{Ecosystem: "npm", Files: []string{"package.json"}},
+				}},
+				{Path: "tools", Detections: []generator.Detection{
+					{Ecosystem: "gomod", Files: []string{"go.mod"}},
+				}},
+			},
+			opts: generator.Options{
+				Schedule: generator.Schedule{Interval: "weekly"},
+				Rules: []generator.PathRule{
+					{
+						Path:     "services/payments/**",
+						Schedule: &generator.Schedule{Interval: "daily"},
+						EntrySettings: generator.EntrySettings{
+							Reviewers:    []string{"acme/payments"},
+							TargetBranch: "develop",
+						},
+					},
+					{
+						Path:      "tools/**",
+						Ecosystem: "gomod",
+						Schedule:  &generator.Schedule{Interval: "monthly"},
+						Grouping:  &generator.Grouping{Strategy: generator.GroupAll},
+					},
+					{
+						Path:          "services/payments/ui",
+						EntrySettings: generator.EntrySettings{Labels: []string{"dependencies", "frontend"}},
+					},
+				},
+			},
+			goldenFile: "rules.golden.yml",
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
-			ecosystemMap, _ := generator.GetEcosystemMap("")
-
-			// Create a temporary directory structure for the test
-			rootDir := t.TempDir()
-			for name, content := range tc.files {
-				filePath := filepath.Join(rootDir, name)
-				if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
-					t.Fatal(err)
-				}
-				if err := os.WriteFile(filePath, []byte(content), 0o644); err != nil {
-					t.Fatal(err)
-				}
-			}
-
-			config, err := generator.GenerateDependabotConfig(rootDir, tc.directories, "daily", ecosystemMap, "")
+			config, err := generator.GenerateDependabotConfig(
+				&generator.ScanResult{Root: ".", Directories: tc.directories},
+				tc.opts,
+			)
 			if err != nil {
 				t.Fatalf("Expected no error, but got %v", err)
 			}
@@ -194,3 +647,1005 @@ func TestGenerateDependabotConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateRenovateConfig(t *testing.T) {
+	t.Parallel()
+	result := &generator.ScanResult{
+		Root: ".",
+		Directories: []generator.Directory{
+			{Path: "/", Detections: []generator.Detection{
+				{Ecosystem: "docker", Rule: "patterns", Files: []string{"Dockerfile"}},
+				{Ecosystem: "github-actions", Rule: "patterns", Files: []string{".github/workflows/ci.yml"}},
+				{Ecosystem: "npm", Rule: "patterns", Files: []string{"package.json"}},
+			}},
+			{Path: "api", Detections: []generator.Detection{
+				{Ecosystem: "pip", Rule: "heuristics[1]", Files: []string{"poetry.lock", "pyproject.toml"}},
+			}},
+			{Path: "docs", Detections: []generator.Detection{
+				{Ecosystem: "elm", Rule: "patterns", Files: []string{"elm.json"}},
+			}},
+			{Path: "tools", Detections: []generator.Detection{
+				{Ecosystem: "uv", Rule: "heuristics[0]", Files: []string{"uv.lock"}},
+			}},
+		},
+		WorkspaceMembers: []generator.WorkspaceMember{
+			{Path: "packages/a", Ecosystem: "npm", Root: "/", Files: []string{"package.json"}},
+		},
+	}
+	opts := generator.Options{
+		Schedule: generator.Schedule{Interval: "weekly", Day: "tuesday", Time: "06:00", Timezone: "Europe/Stockholm"},
+		Ecosystems: map[string]generator.EcosystemOptions{
+			"pip": {Schedule: &generator.Schedule{Interval: "cron", Cronjob: "30 5 * * 1-5"}},
+		},
+	}
+
+	config, err := generator.GenerateRenovateConfig(result, opts)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	goldenPath := filepath.Join("testdata", "renovate.golden.json")
+	expected, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("Failed to read golden file %s: %v", goldenPath, err)
+	}
+	if config != string(expected) {
+		t.Errorf("Generated config does not match golden file.\nGot:\n%s\n\nExpected:\n%s", config, string(expected))
+	}
+}
+
+func TestDependabotConfigMarshal(t *testing.T) {
+	t.Parallel()
+	limit := 0
+	config := &generator.DependabotConfig{
+		Version: 2,
+		Updates: []generator.Update{
+			{
+				PackageEcosystem:      "npm",
+				Directories:           []string{"/", "web: \"app\""},
+				Schedule:              generator.Schedule{Interval: "weekly"},
+				Registries:            generator.Registries{"*"},
+				Labels:                []string{"deps: 'npm'", "#1"},
+				OpenPullRequestsLimit: &limit,
+			},
+		},
+	}
+
+	content, err := config.Marshal()
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	var roundTripped generator.DependabotConfig
+	if err := yaml.Unmarshal(content, &roundTripped); err != nil {
+		t.Fatalf("Generated YAML is invalid: %v\n%s", err, content)
+	}
+	if !reflect.DeepEqual(config, &roundTripped) {
+		t.Errorf("Expected round-tripped config %+v, but got %+v", config, roundTripped)
+	}
+}
+
+func TestMergeAdditionalYAML(t *testing.T) {
+	t.Parallel()
+	base := func() *generator.DependabotConfig {
+		return &generator.DependabotConfig{
+			Version: 2,
+			Updates: []generator.Update{
+				{
+					PackageEcosystem: "gomod",
+					Directories:      []string{"/"},
+					Schedule:         generator.Schedule{Interval: "weekly"},
+					Allow:            []generator.Allow{{DependencyType: "all"}},
+					Groups: map[string]generator.Group{
+						"gomod": {Patterns: []string{"*"}, UpdateTypes: []string{"minor", "patch"}},
+					},
+					Labels: []string{"dependencies"},
+				},
+			},
+		}
+	}
+
+	t.Run("deep merge matching entry", func(t *testing.T) {
+		t.Parallel()
+		merged, err := generator.MergeAdditionalYAML(base(), `
+- package-ecosystem: gomod
+  directory: "."
+  schedule:
+    interval: monthly
+  groups:
+    gomod:
+      update-types: ["patch"]
+  labels: ["go"]
+`)
+		if err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+		if len(merged.Updates) != 1 {
+			t.Fatalf("Expected 1 update entry, but got %d", len(merged.Updates))
+		}
+		update := merged.Updates[0]
+		if update.Directory != "." || update.Directories != nil {
+			t.Errorf("Expected directory to replace directories, but got %q and %v", update.Directory, update.Directories)
+		}
+		if update.Schedule.Interval != "monthly" {
+			t.Errorf("Expected interval 'monthly', but got '%s'", update.Schedule.Interval)
+		}
+		expectedGroup := generator.Group{Patterns: []string{"*"}, UpdateTypes: []string{"patch"}}
+		if !reflect.DeepEqual(update.Groups["gomod"], expectedGroup) {
+			t.Errorf("Expected group %+v, but got %+v", expectedGroup, update.Groups["gomod"])
+		}
+		if !reflect.DeepEqual(update.Labels, []string{"go"}) {
+			t.Errorf("Expected labels [go], but got %v", update.Labels)
+		}
+		if len(update.Allow) != 1 {
+			t.Errorf("Expected allow to be kept, but got %v", update.Allow)
+		}
+	})
+
+	t.Run("split directory out of matching entry", func(t *testing.T) {
+		t.Parallel()
+		config := base()
+		config.Updates[0].Directories = []string{"/", "/api", "/web"}
+		merged, err := generator.MergeAdditionalYAML(config, `
+- package-ecosystem: gomod
+  directories: ["/api", "/"]
+  schedule:
+    interval: monthly
+`)
+		if err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+		if len(merged.Updates) != 2 {
+			t.Fatalf("Expected 2 update entries, but got %+v", merged.Updates)
+		}
+		if remaining := merged.Updates[0]; !reflect.DeepEqual(remaining.Directories, []string{"/web"}) ||
+			remaining.Schedule.Interval != "weekly" {
+			t.Errorf("Expected /web to keep the generated settings, but got %+v", remaining)
+		}
+		update := merged.Updates[1]
+		if !reflect.DeepEqual(update.Directories, []string{"/api", "/"}) || update.Schedule.Interval != "monthly" {
+			t.Errorf("Expected / and /api to be merged, but got %+v", update)
+		}
+		if !reflect.DeepEqual(update.Labels, []string{"dependencies"}) || len(update.Groups) != 1 {
+			t.Errorf("Expected the generated settings to be kept, but got %+v", update)
+		}
+		if err := merged.Validate(); err != nil {
+			t.Errorf("Expected a valid config, but got %v", err)
+		}
+	})
+
+	t.Run("append entry and merge registries", func(t *testing.T) {
+		t.Parallel()
+		merged, err := generator.MergeAdditionalYAML(base(), `
+registries:
+  npm-private:
+    type: npm-registry
+    url: https://npm.example.com
+    token: ${{secrets.NPM_TOKEN}}
+updates:
+  - package-ecosystem: gomod
+    directory: /.tools
+    schedule:
+      interval: monthly
+`)
+		if err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+		if len(merged.Updates) != 2 || merged.Updates[1].Directory != "/.tools" {
+			t.Fatalf("Expected appended .tools entry, but got %+v", merged.Updates)
+		}
+		if merged.Registries["npm-private"].URL != "https://npm.example.com" {
+			t.Errorf("Expected merged registry, but got %+v", merged.Registries)
+		}
+	})
+
+	t.Run("invalid YAML", func(t *testing.T) {
+		t.Parallel()
+		for _, additionalYAML := range []string{
+			"- package-ecosystem: gomod\n directory: /",
+			"- package-ecosystem: gomod\n  directroy: /",
+			"just a string",
+		} {
+			if _, err := generator.MergeAdditionalYAML(base(), additionalYAML); err == nil {
+				t.Errorf("Expected an error for %q, but got nil", additionalYAML)
+			}
+		}
+	})
+}
+
+func TestPreserveManualEntries(t *testing.T) {
+	t.Parallel()
+	generated := &generator.DependabotConfig{
+		Version: 2,
+		Updates: []generator.Update{
+			{PackageEcosystem: "npm", Directories: []string{"/", "web"}},
+			{PackageEcosystem: "docker", Directories: []string{"/"}},
+		},
+	}
+	existing, err := generator.ParseDependabotConfig([]byte(`version: 2
+registries:
+  npm-private:
+    type: npm-registry
+    url: https://npm.example.com
+multi-ecosystem-groups:
+  infrastructure:
+    schedule:
+      interval: weekly
+updates:
+  # dependabot-generate: manual
+  - package-ecosystem: docker
+    directory: /
+    patterns: ["nginx"]
+    multi-ecosystem-group: infrastructure
+    schedule:
+      interval: daily
+  - package-ecosystem: npm
+    directory: /web
+    registries: ["npm-private"]
+    schedule:
+      interval: monthly
+  - package-ecosystem: npm
+    directory: /
+    schedule:
+      interval: weekly
+`))
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	result := generator.PreserveManualEntries(
+		generated,
+		existing,
+		[]generator.ManualEntry{{PackageEcosystem: "npm", Directory: "web"}},
+	)
+
+	var ecosystems []string
+	for _, update := range result.Updates {
+		ecosystems = append(ecosystems, update.PackageEcosystem+":"+update.Schedule.Interval)
+	}
+	// The generated docker entry is fully claimed by the manual one, the
+	// generated npm entry keeps only the root directory.
+	expected := []string{"npm:", "docker:daily", "npm:monthly"}
+	if !reflect.DeepEqual(ecosystems, expected) {
+		t.Fatalf("Expected entries %v, but got %v", expected, ecosystems)
+	}
+	if !reflect.DeepEqual(result.Updates[0].Directories, []string{"/"}) {
+		t.Errorf("Expected generated npm directories [/], but got %v", result.Updates[0].Directories)
+	}
+	if !result.Updates[1].Manual || result.Updates[2].Manual {
+		t.Errorf("Expected only the marked entry to keep the marker, but got %+v", result.Updates)
+	}
+	if _, ok := result.Registries["npm-private"]; !ok {
+		t.Errorf("Expected referenced registry to be carried over, but got %+v", result.Registries)
+	}
+	if _, ok := result.MultiEcosystemGroups["infrastructure"]; !ok {
+		t.Errorf("Expected referenced multi-ecosystem group to be carried over, but got %+v", result.MultiEcosystemGroups)
+	}
+
+	content, err := result.Marshal()
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	if !strings.Contains(string(content), "# "+generator.ManualMarker+"\n  - package-ecosystem: docker") {
+		t.Errorf("Expected marker comment above the manual entry, but got:\n%s", content)
+	}
+}
+
+func TestScheduleValidate(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		schedule generator.Schedule
+		valid    bool
+	}{
+		{name: "daily", schedule: generator.Schedule{Interval: "daily"}, valid: true},
+		{
+			name: "weekly with day, time and timezone",
+			schedule: generator.Schedule{
+				Interval: "weekly",
+				Day:      "monday",
+				Time:     "06:00",
+				Timezone: "Europe/Stockholm",
+			},
+			valid: true,
+		},
+		{name: "cron", schedule: generator.Schedule{Interval: "cron", Cronjob: "0 6 * * 1"}, valid: true},
+		{name: "missing interval", schedule: generator.Schedule{}},
+		{name: "unknown interval", schedule: generator.Schedule{Interval: "hourly"}},
+		{name: "day without weekly", schedule: generator.Schedule{Interval: "daily", Day: "monday"}},
+		{name: "unknown day", schedule: generator.Schedule{Interval: "weekly", Day: "someday"}},
+		{name: "invalid time", schedule: generator.Schedule{Interval: "daily", Time: "6pm"}},
+		{name: "unknown timezone", schedule: generator.Schedule{Interval: "daily", Timezone: "Mars/Olympus"}},
+		{name: "cron without cronjob", schedule: generator.Schedule{Interval: "cron"}},
+		{name: "cronjob without cron", schedule: generator.Schedule{Interval: "daily", Cronjob: "0 6 * * 1"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := tc.schedule.Validate()
+			if tc.valid && err != nil {
+				t.Errorf("Expected no error, but got %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Error("Expected an error, but got nil")
+			}
+		})
+	}
+}
+
+func TestCodeOwners(t *testing.T) {
+	t.Parallel()
+	codeOwners := generator.ParseCodeOwners([]byte(`# Default owners
+*                 @acme/platform
+*.js              @acme/frontend # inline comment
+/docs/*           @acme/docs
+apps/             @acme/apps
+/services/**/go.mod @alice @bob
+/services/legacy/
+`))
+	testCases := []struct {
+		file     string
+		expected []string
+	}{
+		{file: "go.mod", expected: []string{"@acme/platform"}},
+		{file: "web/index.js", expected: []string{"@acme/frontend"}},
+		{file: "docs/package.json", expected: []string{"@acme/docs"}},
+		{file: "docs/site/package.json", expected: []string{"@acme/platform"}},
+		{file: "apps/web/package.json", expected: []string{"@acme/apps"}},
+		{file: "tools/apps/web/package.json", expected: []string{"@acme/apps"}},
+		{file: "apps", expected: []string{"@acme/platform"}},
+		{file: "services/legacy/go.mod", expected: nil},
+		{file: "services/api/go.mod", expected: []string{"@alice", "@bob"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.file, func(t *testing.T) {
+			t.Parallel()
+			if owners := codeOwners.Owners(tc.file); !reflect.DeepEqual(owners, tc.expected) {
+				t.Errorf("Expected owners %v, but got %v", tc.expected, owners)
+			}
+		})
+	}
+}
+
+func TestGroupingValidate(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		grouping generator.Grouping
+		valid    bool
+	}{
+		{name: "default", grouping: generator.Grouping{}, valid: true},
+		{
+			name: "named groups",
+			grouping: generator.Grouping{
+				Strategy: generator.GroupAll,
+				Groups:   map[string]generator.Group{"aws-sdk": {Patterns: []string{"@aws-sdk/*"}}},
+			},
+			valid: true,
+		},
+		{
+			name: "security updates",
+			grouping: generator.Grouping{
+				SecurityUpdates: &generator.Grouping{Strategy: generator.GroupByDirectory},
+			},
+			valid: true,
+		},
+		{name: "unknown strategy", grouping: generator.Grouping{Strategy: "module"}},
+		{
+			name: "unknown security updates strategy",
+			grouping: generator.Grouping{
+				SecurityUpdates: &generator.Grouping{Strategy: "module"},
+			},
+		},
+		{
+			name: "nested security updates",
+			grouping: generator.Grouping{
+				SecurityUpdates: &generator.Grouping{SecurityUpdates: &generator.Grouping{}},
+			},
+		},
+		{
+			name:     "named group without patterns",
+			grouping: generator.Grouping{Groups: map[string]generator.Group{"aws-sdk": {}}},
+		},
+		{
+			name: "named group with unknown update type",
+			grouping: generator.Grouping{Groups: map[string]generator.Group{
+				"aws-sdk": {Patterns: []string{"@aws-sdk/*"}, UpdateTypes: []string{"huge"}},
+			}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := tc.grouping.Validate()
+			if tc.valid && err != nil {
+				t.Errorf("Expected no error, but got %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Error("Expected an error, but got nil")
+			}
+		})
+	}
+}
+
+func TestPathRuleValidate(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name  string
+		rule  generator.PathRule
+		valid bool
+	}{
+		{name: "path", rule: generator.PathRule{Path: "services/**"}, valid: true},
+		{name: "root", rule: generator.PathRule{Path: "/", Ecosystem: "gomod"}, valid: true},
+		{name: "missing path", rule: generator.PathRule{Ecosystem: "gomod"}},
+		{name: "invalid path", rule: generator.PathRule{Path: "services/[a"}},
+		{
+			name: "invalid schedule",
+			rule: generator.PathRule{Path: "services/**", Schedule: &generator.Schedule{Interval: "hourly"}},
+		},
+		{
+			name: "invalid grouping",
+			rule: generator.PathRule{Path: "services/**", Grouping: &generator.Grouping{Strategy: "module"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := tc.rule.Validate()
+			if tc.valid && err != nil {
+				t.Errorf("Expected no error, but got %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Error("Expected an error, but got nil")
+			}
+		})
+	}
+}
+
+func TestDependabotConfigValidate(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name          string
+		content       string
+		expectedError string
+	}{
+		{
+			name: "valid",
+			content: `version: 2
+registries:
+  npm-private:
+    type: npm-registry
+    url: https://npm.example.com
+updates:
+  - package-ecosystem: npm
+    directories: ["/", "/web"]
+    registries: ["npm-private"]
+    schedule:
+      interval: weekly
+    groups:
+      security:
+        applies-to: security-updates
+        patterns: ["*"]
+        update-types: ["minor", "patch"]
+  - package-ecosystem: npm
+    directory: /
+    target-branch: release
+    schedule:
+      interval: monthly
+`,
+		},
+		{
+			name: "valid multi-ecosystem group",
+			content: `version: 2
+multi-ecosystem-groups:
+  infrastructure:
+    schedule:
+      interval: weekly
+    labels: ["infrastructure"]
+updates:
+  - package-ecosystem: docker
+    directory: /
+    patterns: ["nginx", "redis"]
+    multi-ecosystem-group: infrastructure
+  - package-ecosystem: npm
+    directories: ["/", "/web"]
+    exclude-paths: ["vendor/**"]
+    schedule:
+      interval: weekly
+    groups:
+      react:
+        patterns: ["react*"]
+        group-by: dependency-name
+`,
+		},
+		{
+			name: "undefined multi-ecosystem group",
+			content: `version: 2
+updates:
+  - package-ecosystem: docker
+    directory: /
+    multi-ecosystem-group: infrastructure
+`,
+			expectedError: "multi-ecosystem-group 'infrastructure' is not defined",
+		},
+		{
+			name:          "wrong version",
+			content:       "version: 1\nupdates: []\n",
+			expectedError: "version must be 2",
+		},
+		{
+			name: "unknown ecosystem",
+			content: `version: 2
+updates:
+  - package-ecosystem: gomodd
+    directory: /
+    schedule:
+      interval: weekly
+`,
+			expectedError: "updates[0] (gomodd in [/]): unknown package-ecosystem 'gomodd'",
+		},
+		{
+			name: "missing directory",
+			content: `version: 2
+updates:
+  - package-ecosystem: gomod
+    schedule:
+      interval: weekly
+`,
+			expectedError: "one of directory or directories is required",
+		},
+		{
+			name: "directory and directories",
+			content: `version: 2
+updates:
+  - package-ecosystem: gomod
+    directory: /
+    directories: ["/tools"]
+    schedule:
+      interval: weekly
+`,
+			expectedError: "directory and directories are mutually exclusive",
+		},
+		{
+			name: "invalid interval",
+			content: `version: 2
+updates:
+  - package-ecosystem: gomod
+    directory: /
+    schedule:
+      interval: hourly
+`,
+			expectedError: "schedule: unknown interval 'hourly'",
+		},
+		{
+			name: "invalid group update-type",
+			content: `version: 2
+updates:
+  - package-ecosystem: gomod
+    directory: /
+    schedule:
+      interval: weekly
+    groups:
+      gomod:
+        patterns: ["*"]
+        update-types: ["semver-minor"]
+`,
+			expectedError: "groups.gomod: unknown update-type 'semver-minor'",
+		},
+		{
+			name: "undefined registry",
+			content: `version: 2
+updates:
+  - package-ecosystem: npm
+    directory: /
+    registries: ["npm-private"]
+    schedule:
+      interval: weekly
+`,
+			expectedError: "registry 'npm-private' is not defined",
+		},
+		{
+			name: "duplicate directory",
+			content: `version: 2
+updates:
+  - package-ecosystem: gomod
+    directories: ["/", "/tools"]
+    schedule:
+      interval: weekly
+  - package-ecosystem: gomod
+    directory: tools
+    schedule:
+      interval: monthly
+`,
+			expectedError: "updates[1] (gomod in [tools]): directory 'tools' is already covered by updates[0]",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			config, err := generator.ParseDependabotConfig([]byte(tc.content))
+			if err != nil {
+				t.Fatalf("Expected no parse error, but got %v", err)
+			}
+
+			err = config.Validate()
+			if tc.expectedError == "" {
+				if err != nil {
+					t.Errorf("Expected no error, but got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Errorf("Expected error containing %q, but got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestRecursivelyScanDirectoriesGit(t *testing.T) {
+	t.Parallel()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+
+	rootDir := t.TempDir()
+	runGit := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", rootDir}, args...)...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+	runGit("init", "-q")
+
+	files := map[string]string{
+		".gitignore":           "build/\n",
+		".git/info/exclude":    "cache/\n",
+		"go.mod":               "module my-project",
+		"build/package.json":   "{}",
+		"cache/go.mod":         "module cache",
+		"untracked/Cargo.toml": "",
+	}
+	writeFiles(t, rootDir, files)
+	runGit("add", ".gitignore", "go.mod")
+
+	ecosystemMap, _ := generator.GetEcosystemMap("")
+	testCases := []struct {
+		name     string
+		opts     generator.ScanOptions
+		expected []string
+	}{
+		{name: "all files", expected: []string{"/", "build", "cache", "untracked"}},
+		{name: "respect gitignore", opts: generator.ScanOptions{RespectGitignore: true}, expected: []string{"/", "untracked"}},
+		{name: "tracked only", opts: generator.ScanOptions{TrackedOnly: true}, expected: []string{"/"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dirs, err := generator.RecursivelyScanDirectories(rootDir, ecosystemMap, tc.opts)
+			if err != nil {
+				t.Fatalf("Expected no error, but got %v", err)
+			}
+			if !reflect.DeepEqual(dirs.Paths(), tc.expected) {
+				t.Errorf("Expected directories %v, but got %v", tc.expected, dirs)
+			}
+		})
+	}
+
+	t.Run("not a git repository", func(t *testing.T) {
+		t.Parallel()
+		_, err := generator.RecursivelyScanDirectories(
+			t.TempDir(),
+			ecosystemMap,
+			generator.ScanOptions{RespectGitignore: true},
+		)
+		if err == nil {
+			t.Error("Expected an error outside of a git repository, but got nil")
+		}
+	})
+}
+
+func TestRecursivelyScanDirectoriesExcludePaths(t *testing.T) {
+	t.Parallel()
+	rootDir := t.TempDir()
+	for _, dir := range []string{
+		".",
+		"test",
+		"contest",
+		"latest",
+		"examples/a",
+		"examples/keep-me",
+		"examples/keep-me/sub",
+		"pkg/testdata",
+		"pkg/testdata/nested",
+		"node_modules/x",
+		"web/node_modules/y",
+	} {
+		writeFiles(t, rootDir, map[string]string{path.Join(dir, "go.mod"): "module x"})
+	}
+
+	ecosystemMap, _ := generator.GetEcosystemMap("")
+	testCases := []struct {
+		name         string
+		excludePaths []string
+		expected     []string
+	}{
+		{
+			name:         "anchored name",
+			excludePaths: []string{"test"},
+			expected: []string{
+				"/", "contest", "examples/a", "examples/keep-me", "examples/keep-me/sub", "latest",
+				"node_modules/x", "pkg/testdata", "pkg/testdata/nested", "web/node_modules/y",
+			},
+		},
+		{
+			name:         "doublestar",
+			excludePaths: []string{"**/node_modules", "**/testdata", "examples/**", "test/", "./contest"},
+			expected:     []string{"/", "latest"},
+		},
+		{
+			name:         "negation",
+			excludePaths: []string{"examples/**", "!examples/keep-me", "**/node_modules", "pkg", "test*", "!test"},
+			expected:     []string{"/", "contest", "examples/keep-me", "examples/keep-me/sub", "latest", "test"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dirs, err := generator.RecursivelyScanDirectories(
+				rootDir,
+				ecosystemMap,
+				generator.ScanOptions{ExcludePaths: tc.excludePaths},
+			)
+			if err != nil {
+				t.Fatalf("Expected no error, but got %v", err)
+			}
+			if !reflect.DeepEqual(dirs.Paths(), tc.expected) {
+				t.Errorf("Expected directories %v, but got %v", tc.expected, dirs)
+			}
+		})
+	}
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		t.Parallel()
+		_, err := generator.RecursivelyScanDirectories(
+			rootDir,
+			ecosystemMap,
+			generator.ScanOptions{ExcludePaths: []string{"examples/[a"}},
+		)
+		if err == nil {
+			t.Error("Expected an error for an invalid pattern, but got nil")
+		}
+	})
+}
+
+func TestRecursivelyScanDirectoriesWorkspaces(t *testing.T) {
+	t.Parallel()
+	ecosystemMap, _ := generator.GetEcosystemMap("")
+
+	testCases := []struct {
+		name     string
+		files    map[string]string
+		expected map[string][]string
+	}{
+		{
+			name: "npm workspaces",
+			files: map[string]string{
+				"package.json":                     `{"workspaces": ["packages/*", "!packages/standalone"]}`,
+				"packages/a/package.json":          `{}`,
+				"packages/b/package.json":          `{}`,
+				"packages/standalone/package.json": `{}`,
+				"tools/package.json":               `{}`,
+			},
+			expected: map[string][]string{"/": {"npm"}, "packages/standalone": {"npm"}, "tools": {"npm"}},
+		},
+		{
+			name: "yarn workspaces object",
+			files: map[string]string{
+				"web/package.json":            `{"workspaces": {"packages": ["apps/**"]}}`,
+				"web/apps/site/package.json":  `{}`,
+				"web/apps/a/b/c/package.json": `{}`,
+			},
+			expected: map[string][]string{"web": {"npm"}},
+		},
+		{
+			name: "pnpm workspace",
+			files: map[string]string{
+				"package.json":            `{}`,
+				"pnpm-workspace.yaml":     "packages:\n  - 'packages/*'\n",
+				"packages/a/package.json": `{}`,
+			},
+			expected: map[string][]string{"/": {"npm"}},
+		},
+		{
+			name: "cargo workspace",
+			files: map[string]string{
+				"Cargo.toml":               "[workspace]\nmembers = [\"crates/*\"]\nexclude = [\"crates/legacy\"]\n",
+				"crates/a/Cargo.toml":      "[package]\nname = \"a\"\n",
+				"crates/legacy/Cargo.toml": "[package]\nname = \"legacy\"\n",
+			},
+			expected: map[string][]string{"/": {"cargo"}, "crates/legacy": {"cargo"}},
+		},
+		{
+			// Dependabot does not read go.work, every module needs its own
+			// directory.
+			name: "go workspace",
+			files: map[string]string{
+				"go.mod":          "module x",
+				"go.work":         "go 1.24\n\nuse (\n\t.\n\t./cmd/tool\n)\n\nuse ./api\n",
+				"cmd/tool/go.mod": "module x/cmd/tool",
+				"api/go.mod":      "module x/api",
+			},
+			expected: map[string][]string{"/": {"gomod"}, "api": {"gomod"}, "cmd/tool": {"gomod"}},
+		},
+		{
+			name: "member with other ecosystems",
+			files: map[string]string{
+				"package.json":            `{"workspaces": ["packages/*"]}`,
+				"packages/a/package.json": `{}`,
+				"packages/a/Dockerfile":   "FROM scratch",
+			},
+			expected: map[string][]string{"/": {"npm"}, "packages/a": {"docker"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rootDir := t.TempDir()
+			writeFiles(t, rootDir, tc.files)
+
+			result, err := generator.RecursivelyScanDirectories(rootDir, ecosystemMap, generator.ScanOptions{})
+			if err != nil {
+				t.Fatalf("Expected no error, but got %v", err)
+			}
+			dirs := make(map[string][]string)
+			for _, dir := range result.Directories {
+				dirs[dir.Path] = dir.Ecosystems()
+			}
+			if !reflect.DeepEqual(dirs, tc.expected) {
+				t.Errorf("Expected directories %v, but got %v", tc.expected, dirs)
+			}
+		})
+	}
+}
+
+func TestRecursivelyScanDirectoriesMissingRoot(t *testing.T) {
+	t.Parallel()
+	ecosystemMap, _ := generator.GetEcosystemMap("")
+	for _, root := range []string{"", filepath.Join(t.TempDir(), "missing")} {
+		if _, err := generator.RecursivelyScanDirectories(root, ecosystemMap, generator.ScanOptions{}); err == nil {
+			t.Errorf("Expected an error for root %q, but got nil", root)
+		}
+	}
+}
+
+func TestRecursivelyScanDirectoriesWorkers(t *testing.T) {
+	t.Parallel()
+	rootDir := t.TempDir()
+	manifests := []string{"go.mod", "package.json", "Dockerfile", "Cargo.toml", "README.md"}
+	files := make(map[string]string)
+	for i := range 50 {
+		for _, name := range manifests[:i%len(manifests)+1] {
+			files[path.Join("svc", strconv.Itoa(i), name)] = "{}"
+		}
+	}
+	writeFiles(t, rootDir, files)
+
+	ecosystemMap, _ := generator.GetEcosystemMap("")
+	sequential, err := generator.RecursivelyScanDirectories(rootDir, ecosystemMap, generator.ScanOptions{Workers: 1})
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	if len(sequential.Directories) != 50 {
+		t.Fatalf("Expected 50 directories, but got %d", len(sequential.Directories))
+	}
+
+	for _, workers := range []int{0, 4, 64} {
+		concurrent, err := generator.RecursivelyScanDirectories(
+			rootDir,
+			ecosystemMap,
+			generator.ScanOptions{Workers: workers},
+		)
+		if err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+		if !reflect.DeepEqual(concurrent, sequential) {
+			t.Errorf("Expected the same result with %d workers, got %v instead of %v", workers, concurrent, sequential)
+		}
+	}
+}
+
+func TestRecursivelyScanDirectoriesRegistries(t *testing.T) {
+	t.Parallel()
+	rootDir := t.TempDir()
+	files := map[string]string{
+		".npmrc": "registry=https://npm.example.com/\n@acme:registry=https://npm.pkg.github.com\n" +
+			"//npm.pkg.github.com/:_authToken=${NODE_AUTH_TOKEN}\n",
+		"package.json": "{}",
+		"web/.yarnrc.yml": "npmRegistryServer: \"https://registry.yarnpkg.com\"\n" +
+			"npmScopes:\n  acme:\n    npmRegistryServer: \"https://npm.pkg.github.com\"\n",
+		"web/.npmrc":       "@web:registry=https://npm.web.example.com/\n",
+		"web/package.json": "{}",
+		"api/pip.conf":     "[global]\nindex-url = https://pypi.example.com/simple\nextra-index-url =\n  https://pypi.org/simple\n",
+		"api/pyproject.toml": "[project]\nname = \"api\"\n\n[[tool.uv.index]]\nname = \"internal\"\n" +
+			"url = \"https://pypi.example.com/internal\"\n",
+		"api/uv.lock": "",
+		"java/settings.xml": "<settings><mirrors><mirror><mirrorOf>central</mirrorOf>" +
+			"<url>https://nexus.example.com/maven</url></mirror></mirrors></settings>",
+		"java/pom.xml": "<project/>",
+		"dotnet/NuGet.Config": "<configuration><packageSources>" +
+			"<add key=\"nuget.org\" value=\"https://api.nuget.org/v3/index.json\"/>" +
+			"<add key=\"acme\" value=\"https://nuget.example.com/v3/index.json\"/>" +
+			"</packageSources></configuration>",
+		"dotnet/app.csproj": "<Project/>",
+		"build/Dockerfile": "FROM --platform=linux/amd64 registry.example.com/base/go:1.24 AS build\n" +
+			"FROM golang:1.24\nFROM build\n",
+		"api/Dockerfile": "FROM ghcr.io/acme/base:1\nFROM gcr.io/distroless/static\n" +
+			"FROM mcr.microsoft.com/dotnet/sdk:9.0\nFROM public.ecr.aws/lambda/go:1\n",
+		"deploy/compose.yaml": "services:\n  app:\n    image: registry.example.com:5000/app\n" +
+			"  db:\n    image: postgres:17\n  cache:\n    image: quay.io/coreos/etcd\n",
+	}
+	writeFiles(t, rootDir, files)
+
+	ecosystemMap, _ := generator.GetEcosystemMap("")
+	result, err := generator.RecursivelyScanDirectories(rootDir, ecosystemMap, generator.ScanOptions{})
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	if result.Registries != nil {
+		t.Errorf("Expected no registries without the option, but got %+v", result.Registries)
+	}
+	result, err = generator.RecursivelyScanDirectories(
+		rootDir,
+		ecosystemMap,
+		generator.ScanOptions{PrivateRegistries: true},
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	expectedRegistries := []generator.RegistryReference{
+		{Path: "/", File: ".npmrc", Type: "npm-registry", URL: "https://npm.example.com/", ReplacesBase: true},
+		{Path: "/", File: ".npmrc", Type: "npm-registry", URL: "https://npm.pkg.github.com"},
+		{Path: "api", File: "pip.conf", Type: "python-index", URL: "https://pypi.example.com/simple", ReplacesBase: true},
+		{Path: "api", File: "pyproject.toml", Type: "python-index", URL: "https://pypi.example.com/internal"},
+		{Path: "build", File: "Dockerfile", Type: "docker-registry", URL: "registry.example.com"},
+		{Path: "deploy", File: "compose.yaml", Type: "docker-registry", URL: "registry.example.com:5000"},
+		{Path: "dotnet", File: "NuGet.Config", Type: "nuget-feed", URL: "https://nuget.example.com/v3/index.json"},
+		{
+			Path: "java", File: "settings.xml", Type: "maven-repository",
+			URL: "https://nexus.example.com/maven", ReplacesBase: true,
+		},
+		{Path: "web", File: ".npmrc", Type: "npm-registry", URL: "https://npm.web.example.com/"},
+		{Path: "web", File: ".yarnrc.yml", Type: "npm-registry", URL: "https://npm.pkg.github.com"},
+	}
+	if !reflect.DeepEqual(result.Registries, expectedRegistries) {
+		t.Errorf("Expected registries %+v, but got %+v", expectedRegistries, result.Registries)
+	}
+
+	// Only build references a docker registry and only web the registry of
+	// its .npmrc, so their directories get entries of their own.
+	config, err := generator.GenerateDependabotConfig(result, generator.Options{
+		Schedule:          generator.Schedule{Interval: "weekly"},
+		PrivateRegistries: true,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	goldenPath := filepath.Join("testdata", "registries.golden.yml")
+	expected, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("Failed to read golden file %s: %v", goldenPath, err)
+	}
+	if config != string(expected) {
+		t.Errorf("Generated config does not match golden file.\nGot:\n%s\n\nExpected:\n%s", config, expected)
+	}
+}
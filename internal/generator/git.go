@@ -0,0 +1,44 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path"
+)
+
+// listGitDirectories returns every directory below root, relative to root and
+// including root itself as ".", which contains files known to git. With
+// trackedOnly, only files in the index count; otherwise untracked files which
+// are not ignored count as well.
+func listGitDirectories(root string, trackedOnly bool) (map[string]struct{}, error) {
+	args := []string{"-C", root, "ls-files", "-z", "--cached"}
+	if !trackedOnly {
+		args = append(args, "--others", "--exclude-standard")
+	}
+
+	var stderr bytes.Buffer
+	//nolint:gosec // The root is passed as a single argument and not interpreted by a shell.
+	cmd := exec.Command("git", args...)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error listing files known to git in '%s': %w: %s", root, err, bytes.TrimSpace(stderr.Bytes()),
+		)
+	}
+
+	dirs := map[string]struct{}{".": {}}
+	for _, file := range bytes.Split(output, []byte{0}) {
+		if len(file) == 0 {
+			continue
+		}
+		for dir := path.Dir(string(file)); dir != "."; dir = path.Dir(dir) {
+			if _, ok := dirs[dir]; ok {
+				break
+			}
+			dirs[dir] = struct{}{}
+		}
+	}
+	return dirs, nil
+}
@@ -0,0 +1,148 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Grouping strategies, see Grouping.
+const (
+	GroupByEcosystem      = "ecosystem"
+	GroupByDirectory      = "directory"
+	GroupAll              = "all"
+	GroupByDependencyType = "dependency-type"
+	GroupNone             = "none"
+)
+
+// Grouping controls how the updates of an ecosystem are grouped into pull
+// requests.
+type Grouping struct {
+	// Strategy is one of:
+	//   - "ecosystem", the default: minor and patch updates in one group named
+	//     after the ecosystem.
+	//   - "directory": minor and patch updates in one group per directory,
+	//     which splits the update entry into one entry per directory.
+	//   - "all": all updates, including majors, in one group.
+	//   - "dependency-type": minor and patch updates in separate groups for
+	//     production and development dependencies.
+	//   - "none": no groups, i.e. one pull request per dependency.
+	Strategy string `yaml:"strategy,omitempty"`
+	// Groups are additional named groups, e.g. to update all "@aws-sdk/*"
+	// packages together. Their patterns are excluded from the groups of the
+	// strategy, so dependencies end up in the named groups regardless of the
+	// order of the groups.
+	Groups map[string]Group `yaml:"groups,omitempty"`
+	// SecurityUpdates, if set, is the grouping of security updates, which are
+	// otherwise opened one pull request per vulnerable dependency. Its groups
+	// apply to security updates only and are named with a "-security"
+	// suffix, e.g. "gomod-security", to keep them apart from the groups of
+	// version updates.
+	SecurityUpdates *Grouping `yaml:"security-updates,omitempty"`
+}
+
+// Validate checks the strategy and the named groups.
+func (g Grouping) Validate() error {
+	var errs []error
+	if !oneOf(g.Strategy, "", GroupByEcosystem, GroupByDirectory, GroupAll, GroupByDependencyType, GroupNone) {
+		errs = append(errs, fmt.Errorf("unknown strategy '%s'", g.Strategy))
+	}
+	for _, name := range slices.Sorted(maps.Keys(g.Groups)) {
+		group := g.Groups[name]
+		if len(group.Patterns) == 0 {
+			errs = append(errs, fmt.Errorf("groups.%s: patterns are required", name))
+		}
+		for _, err := range validateGroup(group) {
+			errs = append(errs, fmt.Errorf("groups.%s: %w", name, err))
+		}
+	}
+	if g.SecurityUpdates != nil {
+		if g.SecurityUpdates.SecurityUpdates != nil {
+			errs = append(errs, errors.New("security-updates: security-updates cannot be nested"))
+		}
+		if err := g.SecurityUpdates.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("security-updates: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// perDirectory reports whether the version or security update groups are
+// per directory, which takes an update entry per directory.
+func (g Grouping) perDirectory() bool {
+	return g.Strategy == GroupByDirectory || g.SecurityUpdates != nil && g.SecurityUpdates.Strategy == GroupByDirectory
+}
+
+// groups returns the groups of an update entry of the ecosystem, including
+// those of security updates. The directory is only used to name the groups
+// of the "directory" strategy.
+func (g Grouping) groups(ecosystem, directory string) map[string]Group {
+	groups := g.versionGroups(ecosystem, directory)
+	if g.SecurityUpdates != nil {
+		for name, group := range g.SecurityUpdates.versionGroups(ecosystem, directory) {
+			if groups == nil {
+				groups = make(map[string]Group)
+			}
+			group.AppliesTo = "security-updates"
+			groups[name+"-security"] = group
+		}
+	}
+	return groups
+}
+
+// versionGroups returns the groups of the strategy and the named groups.
+func (g Grouping) versionGroups(ecosystem, directory string) map[string]Group {
+	minorAndPatch := []string{"minor", "patch"}
+	var excluded []string
+	for _, name := range slices.Sorted(maps.Keys(g.Groups)) {
+		excluded = append(excluded, g.Groups[name].Patterns...)
+	}
+	catchAll := func(dependencyType string, updateTypes []string) Group {
+		return Group{
+			DependencyType:  dependencyType,
+			Patterns:        []string{"*"},
+			ExcludePatterns: sortedUnique(slices.Clone(excluded)),
+			UpdateTypes:     updateTypes,
+		}
+	}
+
+	groups := maps.Clone(g.Groups)
+	if groups == nil {
+		groups = make(map[string]Group)
+	}
+	// Named groups take precedence over strategy groups of the same name.
+	add := func(name string, group Group) {
+		if _, ok := groups[name]; !ok {
+			groups[name] = group
+		}
+	}
+	switch g.Strategy {
+	case GroupByDirectory:
+		add(ecosystem+"-"+directorySlug(directory), catchAll("", minorAndPatch))
+	case GroupAll:
+		add(ecosystem, catchAll("", nil))
+	case GroupByDependencyType:
+		add(ecosystem+"-production", catchAll("production", minorAndPatch))
+		add(ecosystem+"-development", catchAll("development", minorAndPatch))
+	case GroupNone:
+	default:
+		add(ecosystem, catchAll("", minorAndPatch))
+	}
+
+	if len(groups) == 0 {
+		return nil
+	}
+	return groups
+}
+
+// directorySlug turns a directory into a group name suffix, e.g.
+// "packages/web" into "packages-web" and the root into "root".
+func directorySlug(directory string) string {
+	slug := strings.Trim(strings.ReplaceAll(slashDir(directory), "/", "-"), "-.")
+	if slug == "" {
+		return "root"
+	}
+	return slug
+}
@@ -0,0 +1,119 @@
+package generator
+
+import (
+	"log" //nolint:depguard // No need for slog just yet.
+	"maps"
+	"slices"
+)
+
+// ManualEntry identifies existing update entries which are managed by hand,
+// as an alternative to marking them with a ManualMarker comment.
+type ManualEntry struct {
+	PackageEcosystem string `yaml:"package-ecosystem"`
+	Directory        string `yaml:"directory"`
+}
+
+// PreserveManualEntries returns config with the manually managed entries of
+// existing appended. An entry is manually managed if it is marked with a
+// ManualMarker comment or matches one of manualEntries.
+//
+// Generated entries of the same ecosystem no longer cover the directories of
+// the manual entries, and are dropped if no directories remain. Registries and
+// multi-ecosystem groups referenced by manual entries are carried over from
+// existing.
+func PreserveManualEntries(config, existing *DependabotConfig, manualEntries []ManualEntry) *DependabotConfig {
+	if existing == nil {
+		return config
+	}
+
+	var manual []Update
+	claimed := make(map[string]map[string]struct{})
+	for _, update := range existing.Updates {
+		if !update.Manual && !matchesManualEntry(update, manualEntries) {
+			continue
+		}
+		log.Printf("Preserving manually managed %s entry for %v", update.PackageEcosystem, updateDirectories(update))
+		manual = append(manual, update)
+		if claimed[update.PackageEcosystem] == nil {
+			claimed[update.PackageEcosystem] = make(map[string]struct{})
+		}
+		for _, dir := range updateDirectories(update) {
+			claimed[update.PackageEcosystem][normalizeDirectory(dir)] = struct{}{}
+		}
+	}
+	if len(manual) == 0 {
+		return config
+	}
+
+	result := &DependabotConfig{
+		Version:              config.Version,
+		EnableBetaEcosystems: config.EnableBetaEcosystems,
+		Registries:           maps.Clone(config.Registries),
+		MultiEcosystemGroups: maps.Clone(config.MultiEcosystemGroups),
+	}
+	for _, update := range config.Updates {
+		dirs := claimed[update.PackageEcosystem]
+		if update.Directory != "" {
+			if _, ok := dirs[normalizeDirectory(update.Directory)]; ok {
+				continue
+			}
+		}
+		update.Directories = slices.DeleteFunc(slices.Clone(update.Directories), func(dir string) bool {
+			_, ok := dirs[normalizeDirectory(dir)]
+			return ok
+		})
+		if update.Directory == "" && len(update.Directories) == 0 {
+			continue
+		}
+		result.Updates = append(result.Updates, update)
+	}
+	result.Updates = append(result.Updates, manual...)
+
+	for _, update := range manual {
+		for _, name := range update.Registries {
+			registry, ok := existing.Registries[name]
+			if _, exists := result.Registries[name]; !ok || exists {
+				continue
+			}
+			if result.Registries == nil {
+				result.Registries = make(map[string]Registry)
+			}
+			result.Registries[name] = registry
+		}
+		name := update.MultiEcosystemGroup
+		group, ok := existing.MultiEcosystemGroups[name]
+		if _, exists := result.MultiEcosystemGroups[name]; !ok || exists {
+			continue
+		}
+		if result.MultiEcosystemGroups == nil {
+			result.MultiEcosystemGroups = make(map[string]MultiEcosystemGroup)
+		}
+		result.MultiEcosystemGroups[name] = group
+	}
+
+	return result
+}
+
+// matchesManualEntry reports whether update has the ecosystem of one of
+// manualEntries and covers its directory.
+func matchesManualEntry(update Update, manualEntries []ManualEntry) bool {
+	for _, entry := range manualEntries {
+		if entry.PackageEcosystem != update.PackageEcosystem {
+			continue
+		}
+		for _, dir := range updateDirectories(update) {
+			if normalizeDirectory(dir) == normalizeDirectory(entry.Directory) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// updateDirectories returns the directory or directories of an update entry.
+func updateDirectories(update Update) []string {
+	if update.Directory != "" {
+		return append([]string{update.Directory}, update.Directories...)
+	}
+	return update.Directories
+}
@@ -2,21 +2,25 @@ package generator
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log" //nolint:depguard // No need for slog just yet.
+	"slices"
 )
 
 // --- Type Definitions ---
 
 type Heuristic struct {
-	Present []string `json:"present"`
-	Absent  []string `json:"absent,omitempty"`
+	Present       []string           `json:"present"                  yaml:"present"`
+	Absent        []string           `json:"absent,omitempty"         yaml:"absent,omitempty"`
+	Content       []ContentPredicate `json:"content,omitempty"        yaml:"content,omitempty"`
+	AbsentContent []ContentPredicate `json:"absent-content,omitempty" yaml:"absent-content,omitempty"`
 }
 
 type EcosystemMapEntry struct {
-	Ecosystem  string      `json:"ecosystem"`
-	Patterns   []string    `json:"patterns,omitempty"`
-	Heuristics []Heuristic `json:"heuristics,omitempty"`
+	Ecosystem  string      `json:"ecosystem"            yaml:"ecosystem"`
+	Patterns   []string    `json:"patterns,omitempty"   yaml:"patterns,omitempty"`
+	Heuristics []Heuristic `json:"heuristics,omitempty" yaml:"heuristics,omitempty"`
 }
 
 // --- Default Ecosystem Map ---
@@ -26,6 +30,10 @@ type EcosystemMapEntry struct {
 // Each ecosystem entry can have either:
 //   - `patterns`: An OR condition. Any file matching the glob pattern triggers detection.
 //   - `heuristics` `present` list: An AND condition. All patterns in the list must be matched.
+//   - `heuristics` `content` list: An AND condition on the content of files, see ContentPredicate.
+//
+// Patterns containing a slash are matched against paths relative to the
+// directory, e.g. the workflows of a repository root.
 //
 // List of ecosystems/package managers:
 // https://docs.github.com/en/code-security/dependabot/working-with-dependabot/dependabot-options-reference#package-ecosystem-
@@ -57,6 +65,7 @@ func getDefaultEcosystemMapJSON() string {
 		{"ecosystem": "docker-compose", "patterns": ["docker-compose.y?ml"]},
 		{"ecosystem": "docker", "patterns": ["Dockerfile"]},
 		{"ecosystem": "elm", "patterns": ["elm.json"]},
+		{"ecosystem": "github-actions", "patterns": [".github/workflows/*.yml", ".github/workflows/*.yaml", "action.yml", "action.yaml"]},
 		{"ecosystem": "gitsubmodule", "patterns": [".gitmodules"]},
 		{"ecosystem": "gomod", "patterns": ["go.mod"]},
 		{"ecosystem": "gradle", "patterns": ["build.gradle", "build.gradle.kts"]},
@@ -73,21 +82,65 @@ func getDefaultEcosystemMapJSON() string {
 
 // --- Core Logic ---
 
+// GetEcosystemMap parses customMapJSON and prepends it to the default ecosystem map.
 func GetEcosystemMap(customMapJSON string) ([]EcosystemMapEntry, error) {
-	var defaultMap []EcosystemMapEntry
-	if err := json.Unmarshal([]byte(getDefaultEcosystemMapJSON()), &defaultMap); err != nil {
-		return nil, fmt.Errorf("failed to parse default ecosystem map: %w", err)
+	customMap, err := ParseEcosystemMap(customMapJSON)
+	if err != nil {
+		return nil, err
 	}
+	return ExtendEcosystemMap(customMap)
+}
 
+// ParseEcosystemMap parses and validates a JSON list of ecosystem map entries.
+// An empty string yields an empty map.
+func ParseEcosystemMap(customMapJSON string) ([]EcosystemMapEntry, error) {
 	if customMapJSON == "" {
-		return defaultMap, nil
+		return nil, nil
 	}
 
 	var customMap []EcosystemMapEntry
 	if err := json.Unmarshal([]byte(customMapJSON), &customMap); err != nil {
 		return nil, fmt.Errorf("failed to parse custom-map JSON: %w", err)
 	}
+	if err := validateEcosystemMap(customMap); err != nil {
+		return nil, fmt.Errorf("invalid custom-map: %w", err)
+	}
+	return customMap, nil
+}
+
+// ExtendEcosystemMap prepends customMap to the default ecosystem map, giving
+// the custom entries the highest priority. An invalid custom entry, such as a
+// content predicate with a malformed regex, is an error.
+func ExtendEcosystemMap(customMap []EcosystemMapEntry) ([]EcosystemMapEntry, error) {
+	var defaultMap []EcosystemMapEntry
+	if err := json.Unmarshal([]byte(getDefaultEcosystemMapJSON()), &defaultMap); err != nil {
+		return nil, fmt.Errorf("failed to parse default ecosystem map: %w", err)
+	}
+
+	if len(customMap) == 0 {
+		return defaultMap, nil
+	}
+	if err := validateEcosystemMap(customMap); err != nil {
+		return nil, fmt.Errorf("invalid custom ecosystem map: %w", err)
+	}
 
-	log.Printf("Successfully parsed custom ecosystem map, prepending to defaults: %+v", customMap)
+	log.Printf("Prepending custom ecosystem map to defaults: %+v", customMap)
 	return append(customMap, defaultMap...), nil
 }
+
+// validateEcosystemMap returns the errors of the content predicates of the
+// heuristics in ecosystemMap, which would otherwise only surface while
+// scanning.
+func validateEcosystemMap(ecosystemMap []EcosystemMapEntry) error {
+	var errs []error
+	for _, entry := range ecosystemMap {
+		for i, heuristic := range entry.Heuristics {
+			for _, predicate := range slices.Concat(heuristic.Content, heuristic.AbsentContent) {
+				if err := predicate.validate(); err != nil {
+					errs = append(errs, fmt.Errorf("%s heuristics[%d]: %w", entry.Ecosystem, i, err))
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
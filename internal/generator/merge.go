@@ -0,0 +1,210 @@
+package generator
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MergeAdditionalYAML merges additionalYAML into config. The YAML may either
+// be a complete dependabot config or a bare list of update entries.
+//
+// Update entries sharing a directory with an existing entry of the same
+// package-ecosystem and target-branch are deep-merged over it, with their
+// directories split out of it; other entries are appended.
+// Top-level registries are merged by name.
+func MergeAdditionalYAML(config *DependabotConfig, additionalYAML string) (*DependabotConfig, error) {
+	var additional any
+	if err := yaml.Unmarshal([]byte(additionalYAML), &additional); err != nil {
+		return nil, fmt.Errorf("failed to parse additional YAML: %w", err)
+	}
+
+	var override map[string]any
+	switch v := additional.(type) {
+	case nil:
+		return config, nil
+	case []any:
+		override = map[string]any{"updates": v}
+	case map[string]any:
+		override = v
+	default:
+		return nil, fmt.Errorf("additional YAML must be a mapping or a list of update entries, got %T", additional)
+	}
+
+	base, err := toGenericMap(config)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range override {
+		if key != "updates" {
+			base[key] = deepMerge(base[key], value)
+			continue
+		}
+		overrideUpdates, ok := value.([]any)
+		if !ok {
+			return nil, fmt.Errorf("additional YAML 'updates' must be a list, got %T", value)
+		}
+		baseUpdates, _ := base["updates"].([]any)
+		base["updates"] = mergeUpdates(baseUpdates, overrideUpdates)
+	}
+
+	content, err := yaml.Marshal(base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal merged config: %w", err)
+	}
+	merged, err := ParseDependabotConfig(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid additional YAML: %w", err)
+	}
+	return merged, nil
+}
+
+// mergeUpdates deep-merges each override entry into the first base entry of
+// the same package-ecosystem and target-branch covering any of its
+// directories, or appends it if there is none. The directories of the
+// override are split out of the base entries covering them, so that the
+// merged entry covers exactly those of the override.
+func mergeUpdates(base, overrides []any) []any {
+	for _, override := range overrides {
+		overrideEntry, ok := override.(map[string]any)
+		if !ok {
+			base = append(base, override)
+			continue
+		}
+		dirs := entryDirectories(overrideEntry)
+
+		var matched map[string]any
+		position := len(base)
+		kept := make([]any, 0, len(base)+1)
+		for _, entry := range base {
+			baseEntry, ok := entry.(map[string]any)
+			if !ok || updateKey(baseEntry) != updateKey(overrideEntry) || !overlaps(entryDirectories(baseEntry), dirs) {
+				kept = append(kept, entry)
+				continue
+			}
+			if remaining := withoutDirectories(baseEntry, dirs); remaining != nil {
+				kept = append(kept, remaining)
+			}
+			if matched == nil {
+				matched = baseEntry
+				position = len(kept)
+			}
+		}
+		if matched == nil {
+			base = append(base, overrideEntry)
+			continue
+		}
+
+		merged, _ := deepMerge(matched, overrideEntry).(map[string]any)
+		// directory and directories are mutually exclusive; the override decides.
+		if _, ok := overrideEntry["directory"]; ok {
+			delete(merged, "directories")
+		}
+		if _, ok := overrideEntry["directories"]; ok {
+			delete(merged, "directory")
+		}
+		base = slices.Insert(kept, position, any(merged))
+	}
+	return base
+}
+
+// updateKey identifies the update entries which may not share directories by
+// their package-ecosystem and target-branch.
+func updateKey(entry map[string]any) string {
+	ecosystem, _ := entry["package-ecosystem"].(string)
+	targetBranch, _ := entry["target-branch"].(string)
+	return ecosystem + ":" + targetBranch
+}
+
+// entryDirectories returns the normalized directories of an update entry.
+func entryDirectories(entry map[string]any) map[string]bool {
+	dirs := make(map[string]bool)
+	if dir, ok := entry["directory"].(string); ok {
+		dirs[normalizeDirectory(dir)] = true
+	}
+	if list, ok := entry["directories"].([]any); ok {
+		for _, d := range list {
+			if dir, ok := d.(string); ok {
+				dirs[normalizeDirectory(dir)] = true
+			}
+		}
+	}
+	return dirs
+}
+
+// overlaps reports whether the directory sets share a directory.
+func overlaps(a, b map[string]bool) bool {
+	for dir := range a {
+		if b[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutDirectories returns a copy of entry without dirs, or nil if it
+// covers no other directories.
+func withoutDirectories(entry map[string]any, dirs map[string]bool) map[string]any {
+	remaining := maps.Clone(entry)
+	if dir, ok := entry["directory"].(string); ok && dirs[normalizeDirectory(dir)] {
+		delete(remaining, "directory")
+	}
+	if list, ok := entry["directories"].([]any); ok {
+		list = slices.DeleteFunc(slices.Clone(list), func(d any) bool {
+			dir, ok := d.(string)
+			return ok && dirs[normalizeDirectory(dir)]
+		})
+		if len(list) > 0 {
+			remaining["directories"] = list
+		} else {
+			delete(remaining, "directories")
+		}
+	}
+	if _, ok := remaining["directory"]; !ok && remaining["directories"] == nil {
+		return nil
+	}
+	return remaining
+}
+
+// normalizeDirectory makes directories comparable, e.g. ".", "/" and "./"
+// all refer to the repository root.
+func normalizeDirectory(dir string) string {
+	return path.Clean("/" + strings.TrimPrefix(dir, "./"))
+}
+
+// deepMerge merges override into base. Mappings are merged recursively, any
+// other value in override replaces the one in base.
+func deepMerge(base, override any) any {
+	baseMap, baseOK := base.(map[string]any)
+	overrideMap, overrideOK := override.(map[string]any)
+	if !baseOK || !overrideOK {
+		return override
+	}
+
+	merged := make(map[string]any, len(baseMap)+len(overrideMap))
+	for key, value := range baseMap {
+		merged[key] = value
+	}
+	for key, value := range overrideMap {
+		merged[key] = deepMerge(merged[key], value)
+	}
+	return merged
+}
+
+// toGenericMap converts config into the generic representation used for merging.
+func toGenericMap(config *DependabotConfig) (map[string]any, error) {
+	content, err := config.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	var generic map[string]any
+	if err := yaml.Unmarshal(content, &generic); err != nil {
+		return nil, fmt.Errorf("failed to convert dependabot config: %w", err)
+	}
+	return generic, nil
+}
@@ -0,0 +1,485 @@
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/xml"
+	"fmt"
+	"log" //nolint:depguard // No need for slog just yet.
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Registry types of the registries found by the scan.
+const (
+	registryTypeDocker = "docker-registry"
+	registryTypeMaven  = "maven-repository"
+	registryTypeNPM    = "npm-registry"
+	registryTypeNuGet  = "nuget-feed"
+	registryTypePython = "python-index"
+)
+
+// RegistryReference is a private registry referenced by a file of the
+// scanned repository.
+type RegistryReference struct {
+	// Path is the directory of the file, relative to the scanned root, with
+	// the root itself as "/".
+	Path string `json:"path"`
+	// File is the referencing file, relative to the directory.
+	File string `json:"file"`
+	// Type is the dependabot registry type, e.g. "npm-registry".
+	Type string `json:"type"`
+	URL  string `json:"url"`
+	// ReplacesBase is set if the registry replaces the default registry of
+	// its ecosystem, rather than serving some packages only.
+	ReplacesBase bool `json:"replaces-base,omitempty"`
+}
+
+// registryParser extracts the registries referenced by the contents of a file.
+type registryParser func(data []byte) ([]RegistryReference, error)
+
+// registryParserFor returns the parser of a file name, or nil if the file
+// does not reference registries.
+func registryParserFor(name string) registryParser {
+	switch {
+	case name == ".npmrc":
+		return parseNpmrc
+	case name == ".yarnrc.yml":
+		return parseYarnrc
+	case name == "pip.conf":
+		return parsePipConf
+	case name == "pyproject.toml":
+		return parsePyproject
+	case name == "settings.xml":
+		return parseMavenSettings
+	case strings.EqualFold(name, "nuget.config"):
+		return parseNuGetConfig
+	case name == "Dockerfile" || strings.HasPrefix(name, "Dockerfile.") ||
+		strings.HasSuffix(strings.ToLower(name), ".dockerfile"):
+		return parseDockerfile
+	case slices.Contains([]string{"docker-compose.yml", "docker-compose.yaml", "compose.yml", "compose.yaml"}, name):
+		return parseCompose
+	}
+	return nil
+}
+
+// discoverRegistries returns the private registries referenced by the files
+// of a directory. Files which cannot be parsed are skipped with a warning.
+func discoverRegistries(directory string) ([]RegistryReference, error) {
+	filesInDir, err := getFilesInDir(directory)
+	if err != nil {
+		return nil, fmt.Errorf("could not read directory %s: %w", directory, err)
+	}
+
+	var references []RegistryReference
+	for _, file := range filesInDir {
+		parse := registryParserFor(file)
+		if parse == nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(directory, file))
+		if err != nil {
+			return nil, err
+		}
+		found, err := parse(data)
+		if err != nil {
+			log.Printf("Warning: could not parse %s in %s: %v", file, directory, err)
+			continue
+		}
+		for _, reference := range found {
+			if isPublicRegistry(reference.URL) {
+				continue
+			}
+			reference.File = file
+			references = append(references, reference)
+		}
+	}
+	return references, nil
+}
+
+// isPublicRegistry reports whether the URL points at the default public
+// registry of an ecosystem, or at a well-known public container registry,
+// which need no configuration.
+func isPublicRegistry(registryURL string) bool {
+	host := registryHost(registryURL)
+	return slices.Contains([]string{
+		"registry.npmjs.org", "registry.yarnpkg.com",
+		"pypi.org", "pypi.python.org", "files.pythonhosted.org",
+		"repo.maven.apache.org", "repo1.maven.org",
+		"api.nuget.org",
+		"docker.io", "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com",
+		"gcr.io", "k8s.gcr.io", "ghcr.io", "mcr.microsoft.com", "quay.io", "public.ecr.aws", "registry.k8s.io",
+		"registry.access.redhat.com", "registry.gitlab.com", "docker.elastic.co", "cgr.dev", "lscr.io", "nvcr.io",
+	}, host) || slices.ContainsFunc([]string{".gcr.io"}, func(suffix string) bool {
+		return strings.HasSuffix(host, suffix)
+	})
+}
+
+// registryHost returns the host of a registry URL, which may lack a scheme.
+func registryHost(registryURL string) string {
+	if !strings.Contains(registryURL, "://") {
+		registryURL = "https://" + registryURL
+	}
+	u, err := url.Parse(registryURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// parseNpmrc reads "registry" and "@scope:registry" settings of an .npmrc.
+func parseNpmrc(data []byte) ([]RegistryReference, error) {
+	var references []RegistryReference
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		switch {
+		case key == "registry":
+			references = append(references, RegistryReference{Type: registryTypeNPM, URL: value, ReplacesBase: true})
+		case strings.HasPrefix(key, "@") && strings.HasSuffix(key, ":registry"):
+			references = append(references, RegistryReference{Type: registryTypeNPM, URL: value})
+		}
+	}
+	return references, scanner.Err()
+}
+
+// parseYarnrc reads the npmRegistryServer settings of a .yarnrc.yml, also
+// those of npmScopes.
+func parseYarnrc(data []byte) ([]RegistryReference, error) {
+	var yarnrc struct {
+		NpmRegistryServer string `yaml:"npmRegistryServer"`
+		NpmScopes         map[string]struct {
+			NpmRegistryServer string `yaml:"npmRegistryServer"`
+		} `yaml:"npmScopes"`
+	}
+	if err := yaml.Unmarshal(data, &yarnrc); err != nil {
+		return nil, err
+	}
+
+	var references []RegistryReference
+	if yarnrc.NpmRegistryServer != "" {
+		references = append(references, RegistryReference{
+			Type: registryTypeNPM, URL: yarnrc.NpmRegistryServer, ReplacesBase: true,
+		})
+	}
+	for _, scope := range yarnrc.NpmScopes {
+		if scope.NpmRegistryServer != "" {
+			references = append(references, RegistryReference{Type: registryTypeNPM, URL: scope.NpmRegistryServer})
+		}
+	}
+	return references, nil
+}
+
+// parsePipConf reads the index-url and extra-index-url settings of a
+// pip.conf. The latter may hold several URLs, also on continuation lines.
+func parsePipConf(data []byte) ([]RegistryReference, error) {
+	var references []RegistryReference
+	var key string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		value := line
+		if line == "" || line[0] != ' ' && line[0] != '\t' {
+			var ok bool
+			key, value, ok = strings.Cut(line, "=")
+			if !ok {
+				key = ""
+				continue
+			}
+			key = strings.TrimSpace(key)
+		}
+		for _, u := range strings.Fields(value) {
+			switch key {
+			case "index-url":
+				references = append(references, RegistryReference{Type: registryTypePython, URL: u, ReplacesBase: true})
+			case "extra-index-url":
+				references = append(references, RegistryReference{Type: registryTypePython, URL: u})
+			}
+		}
+	}
+	return references, scanner.Err()
+}
+
+// parsePyproject reads the Poetry sources and uv indexes of a pyproject.toml.
+func parsePyproject(data []byte) ([]RegistryReference, error) {
+	var pyproject struct {
+		Tool struct {
+			Poetry struct {
+				Source []struct {
+					URL      string `toml:"url"`
+					Priority string `toml:"priority"`
+					Default  bool   `toml:"default"`
+				} `toml:"source"`
+			} `toml:"poetry"`
+			UV struct {
+				Index []struct {
+					URL     string `toml:"url"`
+					Default bool   `toml:"default"`
+				} `toml:"index"`
+			} `toml:"uv"`
+		} `toml:"tool"`
+	}
+	if err := toml.Unmarshal(data, &pyproject); err != nil {
+		return nil, err
+	}
+
+	var references []RegistryReference
+	for _, source := range pyproject.Tool.Poetry.Source {
+		if source.URL != "" {
+			references = append(references, RegistryReference{
+				Type: registryTypePython, URL: source.URL, ReplacesBase: source.Default || source.Priority == "default",
+			})
+		}
+	}
+	for _, index := range pyproject.Tool.UV.Index {
+		if index.URL != "" {
+			references = append(references, RegistryReference{
+				Type: registryTypePython, URL: index.URL, ReplacesBase: index.Default,
+			})
+		}
+	}
+	return references, nil
+}
+
+// parseMavenSettings reads the mirrors and the repositories of the profiles
+// of a Maven settings.xml.
+func parseMavenSettings(data []byte) ([]RegistryReference, error) {
+	type repository struct {
+		URL string `xml:"url"`
+	}
+	var settings struct {
+		Mirrors []struct {
+			URL      string `xml:"url"`
+			MirrorOf string `xml:"mirrorOf"`
+		} `xml:"mirrors>mirror"`
+		Repositories       []repository `xml:"profiles>profile>repositories>repository"`
+		PluginRepositories []repository `xml:"profiles>profile>pluginRepositories>pluginRepository"`
+	}
+	if err := xml.Unmarshal(data, &settings); err != nil {
+		return nil, err
+	}
+
+	var references []RegistryReference
+	for _, mirror := range settings.Mirrors {
+		references = append(references, RegistryReference{
+			Type: registryTypeMaven, URL: mirror.URL, ReplacesBase: mirror.MirrorOf == "*" || mirror.MirrorOf == "central",
+		})
+	}
+	for _, repo := range slices.Concat(settings.Repositories, settings.PluginRepositories) {
+		references = append(references, RegistryReference{Type: registryTypeMaven, URL: repo.URL})
+	}
+	return references, nil
+}
+
+// parseNuGetConfig reads the package sources of a NuGet.Config.
+func parseNuGetConfig(data []byte) ([]RegistryReference, error) {
+	var nugetConfig struct {
+		Sources []struct {
+			Value string `xml:"value,attr"`
+		} `xml:"packageSources>add"`
+	}
+	if err := xml.Unmarshal(data, &nugetConfig); err != nil {
+		return nil, err
+	}
+
+	var references []RegistryReference
+	for _, source := range nugetConfig.Sources {
+		if strings.HasPrefix(source.Value, "http") {
+			references = append(references, RegistryReference{Type: registryTypeNuGet, URL: source.Value})
+		}
+	}
+	return references, nil
+}
+
+// parseDockerfile reads the registries of the images of FROM instructions.
+func parseDockerfile(data []byte) ([]RegistryReference, error) {
+	var references []RegistryReference
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || !strings.EqualFold(fields[0], "FROM") {
+			continue
+		}
+		// Skip flags such as --platform.
+		image := fields[1]
+		for i := 2; strings.HasPrefix(image, "--") && i < len(fields); i++ {
+			image = fields[i]
+		}
+		if host := imageRegistry(image); host != "" {
+			references = append(references, RegistryReference{Type: registryTypeDocker, URL: host})
+		}
+	}
+	return references, scanner.Err()
+}
+
+// parseCompose reads the registries of the service images of a Compose file.
+func parseCompose(data []byte) ([]RegistryReference, error) {
+	var compose struct {
+		Services map[string]struct {
+			Image string `yaml:"image"`
+		} `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(data, &compose); err != nil {
+		return nil, err
+	}
+
+	var references []RegistryReference
+	for _, service := range compose.Services {
+		if host := imageRegistry(service.Image); host != "" {
+			references = append(references, RegistryReference{Type: registryTypeDocker, URL: host})
+		}
+	}
+	return references, nil
+}
+
+// imageRegistry returns the registry host of an image reference, e.g.
+// "registry.example.com" of "registry.example.com/team/app:1.0", or "" for
+// images of Docker Hub and references with variables.
+func imageRegistry(image string) string {
+	if strings.Contains(image, "$") {
+		return ""
+	}
+	host, _, ok := strings.Cut(image, "/")
+	if !ok || !strings.ContainsAny(host, ".:") && host != "localhost" {
+		return ""
+	}
+	return host
+}
+
+// registryEcosystems returns the package ecosystems which use registries of
+// the type.
+func registryEcosystems(registryType string) []string {
+	switch registryType {
+	case registryTypeDocker:
+		return []string{"docker", "docker-compose"}
+	case registryTypeMaven:
+		return []string{"gradle", "maven"}
+	case registryTypeNPM:
+		return []string{"bun", "npm"}
+	case registryTypeNuGet:
+		return []string{"nuget"}
+	case registryTypePython:
+		return []string{"pip", "uv"}
+	}
+	return nil
+}
+
+// privateRegistries returns the top-level registries of the references,
+// keyed by name, along with the directories referencing each of them.
+// Registries are named after their type and host, e.g. "npm-npm-example-com",
+// and after their path as well if several registries share the host. Their
+// credentials are placeholders for secrets named after the registry, e.g.
+// NPM_NPM_EXAMPLE_COM_TOKEN.
+func privateRegistries(references []RegistryReference) (map[string]Registry, map[string][]string) {
+	type key struct{ registryType, url string }
+	replacesBase := make(map[key]bool)
+	for _, reference := range references {
+		k := key{reference.Type, strings.TrimSuffix(reference.URL, "/")}
+		replacesBase[k] = replacesBase[k] || reference.ReplacesBase
+	}
+	hosts := make(map[string]int)
+	for k := range replacesBase {
+		hosts[registryName(k.registryType, k.url, false)]++
+	}
+
+	keys := slices.SortedFunc(maps.Keys(replacesBase), func(a, b key) int {
+		return cmp.Or(cmp.Compare(a.registryType, b.registryType), cmp.Compare(a.url, b.url))
+	})
+	registries := make(map[string]Registry)
+	names := make(map[key]string)
+	for _, k := range keys {
+		base := registryName(k.registryType, k.url, hosts[registryName(k.registryType, k.url, false)] > 1)
+		name := base
+		// Numbered names set apart URLs which differ in their scheme only.
+		for i := 2; registries[name].Type != ""; i++ {
+			name = fmt.Sprintf("%s-%d", base, i)
+		}
+		replaces := replacesBase[k]
+		secret := "${{secrets." + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+		registry := Registry{Type: k.registryType, URL: k.url, ReplacesBase: replaces}
+		if k.registryType == registryTypeNPM || k.registryType == registryTypeNuGet {
+			registry.Token = secret + "_TOKEN}}"
+		} else {
+			registry.Username = secret + "_USERNAME}}"
+			registry.Password = secret + "_PASSWORD}}"
+		}
+		registries[name] = registry
+		names[k] = name
+	}
+	if len(registries) == 0 {
+		return nil, nil
+	}
+
+	referencedFrom := make(map[string][]string)
+	for _, reference := range references {
+		name := names[key{reference.Type, strings.TrimSuffix(reference.URL, "/")}]
+		if !slices.Contains(referencedFrom[name], reference.Path) {
+			referencedFrom[name] = append(referencedFrom[name], reference.Path)
+		}
+	}
+	return registries, referencedFrom
+}
+
+// entryRegistries returns the sorted names of the registries an update entry
+// of the ecosystem needs: those of its type referenced from one of the
+// directories or from a parent directory, such as an .npmrc at the root.
+func entryRegistries(
+	registries map[string]Registry,
+	referencedFrom map[string][]string,
+	ecosystem string,
+	dirs []string,
+) []string {
+	var names []string
+	for _, name := range slices.Sorted(maps.Keys(registries)) {
+		if !slices.Contains(registryEcosystems(registries[name].Type), ecosystem) {
+			continue
+		}
+		if slices.ContainsFunc(referencedFrom[name], func(path string) bool {
+			return slices.ContainsFunc(dirs, func(dir string) bool { return isWithin(dir, path) })
+		}) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// isWithin reports whether dir is the directory parent or one below it.
+func isWithin(dir, parent string) bool {
+	dir, parent = directoryKey(dir), directoryKey(parent)
+	return parent == "." || dir == parent || strings.HasPrefix(dir, parent+"/")
+}
+
+// registryName returns the name of a registry, made up of its type and host
+// and, if withPath is set, its path.
+func registryName(registryType, registryURL string, withPath bool) string {
+	prefix, _, _ := strings.Cut(registryType, "-")
+	if !strings.Contains(registryURL, "://") {
+		registryURL = "https://" + registryURL
+	}
+	var name string
+	if u, err := url.Parse(registryURL); err == nil {
+		name = u.Host
+		if withPath {
+			name += u.Path
+		}
+	}
+	slug := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(name))
+	return prefix + "-" + strings.Trim(slug, "-")
+}
@@ -0,0 +1,241 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"log" //nolint:depguard // No need for slog just yet.
+	"maps"
+	"path"
+	"slices"
+	"strings"
+)
+
+// RenovateSchema is the JSON schema of the Renovate configuration.
+const RenovateSchema = "https://docs.renovatebot.com/renovate-schema.json"
+
+// RenovateConfig is the subset of the Renovate configuration needed to
+// mirror the dependabot configuration built by BuildDependabotConfig.
+type RenovateConfig struct {
+	Schema          string                `json:"$schema"`
+	EnabledManagers []string              `json:"enabledManagers"`
+	IncludePaths    []string              `json:"includePaths"`
+	Schedule        []string              `json:"schedule,omitempty"`
+	Timezone        string                `json:"timezone,omitempty"`
+	Labels          []string              `json:"labels,omitempty"`
+	PackageRules    []RenovatePackageRule `json:"packageRules,omitempty"`
+}
+
+// RenovatePackageRule applies settings to the updates matching all of its
+// match fields.
+type RenovatePackageRule struct {
+	Description      string   `json:"description,omitempty"`
+	MatchManagers    []string `json:"matchManagers,omitempty"`
+	MatchUpdateTypes []string `json:"matchUpdateTypes,omitempty"`
+	GroupName        string   `json:"groupName,omitempty"`
+	Schedule         []string `json:"schedule,omitempty"`
+}
+
+// renovateManagers maps dependabot package ecosystems to the Renovate
+// managers covering the same files.
+func renovateManagers(ecosystem string) []string {
+	switch ecosystem {
+	case "devcontainers":
+		return []string{"devcontainer"}
+	case "docker":
+		return []string{"dockerfile"}
+	case "gitsubmodule":
+		return []string{"git-submodules"}
+	case "helm":
+		return []string{"helmv3"}
+	case "pip":
+		return []string{"pep621", "pip_requirements", "pipenv", "poetry"}
+	case "uv":
+		return []string{"pep621"}
+	case "bun", "bundler", "cargo", "composer", "docker-compose", "github-actions", "gomod", "gradle",
+		"maven", "mix", "npm", "nuget", "pre-commit", "pub", "swift", "terraform":
+		return []string{ecosystem}
+	}
+	return nil
+}
+
+// GenerateRenovateConfig renders the Renovate configuration for the
+// directories of a scan as JSON.
+func GenerateRenovateConfig(result *ScanResult, opts Options) (string, error) {
+	config, err := BuildRenovateConfig(result, opts)
+	if err != nil {
+		return "", err
+	}
+	content, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error encoding renovate config: %w", err)
+	}
+	return string(content) + "\n", nil
+}
+
+// BuildRenovateConfig returns a Renovate configuration equivalent to the
+// dependabot configuration of BuildDependabotConfig: only the managers of the
+// detected ecosystems are enabled, only the detected directories are
+// included, and minor and patch updates are grouped per ecosystem and labeled
+// "dependencies". Ecosystems sharing a manager, such as pip and uv, share a
+// group, as Renovate cannot tell their updates apart. Of opts, only the
+// schedules apply; callers should reject the other options rather than drop
+// them. Ecosystems without a Renovate manager are left out.
+func BuildRenovateConfig(result *ScanResult, opts Options) (*RenovateConfig, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	config := &RenovateConfig{
+		Schema:   RenovateSchema,
+		Schedule: []string{renovateSchedule(opts.Schedule)},
+		Timezone: opts.Schedule.Timezone,
+		Labels:   []string{"dependencies"},
+	}
+
+	// Renovate reads more files than those which triggered a detection, such
+	// as the pyproject.toml next to a uv.lock, so the directories of the
+	// detected files are included. Workspace members are updated through
+	// their own manifests, so their directories are included along with
+	// those of the workspace roots.
+	ecosystemPaths := make(map[string][]string)
+	for _, dir := range result.Directories {
+		for _, detection := range dir.Detections {
+			for _, file := range detection.Files {
+				ecosystemPaths[detection.Ecosystem] = append(ecosystemPaths[detection.Ecosystem],
+					renovateIncludePath(dir.Path, file))
+			}
+		}
+	}
+	for _, member := range result.WorkspaceMembers {
+		for _, file := range member.Files {
+			ecosystemPaths[member.Ecosystem] = append(ecosystemPaths[member.Ecosystem],
+				renovateIncludePath(member.Path, file))
+		}
+	}
+
+	var ecosystems []string
+	config.EnabledManagers = []string{}
+	config.IncludePaths = []string{}
+	for _, eco := range slices.Sorted(maps.Keys(ecosystemPaths)) {
+		if len(renovateManagers(eco)) == 0 {
+			log.Printf("Warning: %s has no Renovate manager and is left out", eco)
+			continue
+		}
+		ecosystems = append(ecosystems, eco)
+		config.IncludePaths = append(config.IncludePaths, ecosystemPaths[eco]...)
+		config.EnabledManagers = append(config.EnabledManagers, renovateManagers(eco)...)
+	}
+	for _, group := range renovateGroups(ecosystems) {
+		name := strings.Join(group.ecosystems, " and ")
+		config.PackageRules = append(config.PackageRules, RenovatePackageRule{
+			Description:      fmt.Sprintf("Group minor and patch updates of %s", name),
+			MatchManagers:    group.managers,
+			MatchUpdateTypes: []string{"minor", "patch"},
+			GroupName:        name,
+		})
+		if schedule := groupSchedule(group, opts.Ecosystems); schedule != nil {
+			if schedule.Timezone != "" && schedule.Timezone != config.Timezone {
+				log.Printf("Warning: Renovate has a single timezone, ignoring '%s' of %s", schedule.Timezone, name)
+			}
+			config.PackageRules = append(config.PackageRules, RenovatePackageRule{
+				Description:   fmt.Sprintf("Schedule of %s", name),
+				MatchManagers: group.managers,
+				Schedule:      []string{renovateSchedule(*schedule)},
+			})
+		}
+	}
+	config.EnabledManagers = sortedUnique(config.EnabledManagers)
+	config.IncludePaths = sortedUnique(config.IncludePaths)
+	return config, nil
+}
+
+// renovateIncludePath returns the Renovate include path of the directory of
+// a file detected in dir.
+func renovateIncludePath(dir, file string) string {
+	return path.Join(slashDir(dir), path.Dir(file), "*")
+}
+
+// renovateGroup is a set of ecosystems updated by the same Renovate managers.
+type renovateGroup struct {
+	ecosystems []string
+	managers   []string
+}
+
+// renovateGroups returns a group per ecosystem, merging those of ecosystems
+// which share a manager.
+func renovateGroups(ecosystems []string) []renovateGroup {
+	var groups []renovateGroup
+	for _, eco := range ecosystems {
+		group := renovateGroup{ecosystems: []string{eco}, managers: renovateManagers(eco)}
+		groups = slices.DeleteFunc(groups, func(other renovateGroup) bool {
+			if !slices.ContainsFunc(other.managers, func(m string) bool { return slices.Contains(group.managers, m) }) {
+				return false
+			}
+			group.ecosystems = append(other.ecosystems, group.ecosystems...)
+			group.managers = sortedUnique(append(other.managers, group.managers...))
+			return true
+		})
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// groupSchedule returns the schedule of the first ecosystem of group which
+// has one. Renovate cannot schedule ecosystems sharing a manager apart, so
+// differing schedules of the others are ignored.
+func groupSchedule(group renovateGroup, ecosystems map[string]EcosystemOptions) *Schedule {
+	var schedule *Schedule
+	for _, eco := range group.ecosystems {
+		ecoSchedule := ecosystems[eco].Schedule
+		switch {
+		case ecoSchedule == nil:
+		case schedule == nil:
+			schedule = ecoSchedule
+		case *ecoSchedule != *schedule:
+			log.Printf("Warning: %s share Renovate managers, ignoring the schedule of %s",
+				strings.Join(group.ecosystems, " and "), eco)
+		}
+	}
+	return schedule
+}
+
+// renovateSchedule converts a dependabot schedule to a Renovate cron
+// schedule. Renovate schedules are windows with hour granularity, so updates
+// run within the hour of the schedule's time, or before 4am without one.
+func renovateSchedule(schedule Schedule) string {
+	hour := "0-3"
+	if schedule.Time != "" {
+		h, _, _ := strings.Cut(schedule.Time, ":")
+		hour = strings.TrimPrefix(h, "0")
+		if hour == "" {
+			hour = "0"
+		}
+	}
+
+	switch schedule.Interval {
+	case "weekly":
+		day := slices.Index([]string{
+			"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday",
+		}, schedule.Day)
+		if day < 0 {
+			day = 1
+		}
+		return fmt.Sprintf("* %s * * %d", hour, day)
+	case "monthly":
+		return fmt.Sprintf("* %s 1 * *", hour)
+	case "quarterly":
+		return fmt.Sprintf("* %s 1 */3 *", hour)
+	case "semiannually":
+		return fmt.Sprintf("* %s 1 */6 *", hour)
+	case "yearly":
+		return fmt.Sprintf("* %s 1 1 *", hour)
+	case "cron":
+		// Renovate schedules do not support minutes.
+		fields := strings.Fields(schedule.Cronjob)
+		if len(fields) == 5 {
+			fields[0] = "*"
+		}
+		return strings.Join(fields, " ")
+	}
+	return fmt.Sprintf("* %s * * *", hour)
+}
@@ -0,0 +1,134 @@
+package generator
+
+import (
+	"cmp"
+	"path"
+	"slices"
+	"sort"
+)
+
+// ScanResult is the outcome of RecursivelyScanDirectories. It records what
+// was detected where, so that the generator and other output formats work
+// from the same view of the repository without reading it again.
+type ScanResult struct {
+	// Root is the scanned path.
+	Root string `json:"root"`
+	// Directories are the directories with package ecosystems, sorted by path.
+	Directories []Directory `json:"directories"`
+	// Excluded are the directories skipped according to ScanOptions, in walk
+	// order. Directories below them are not listed.
+	Excluded []ExcludedDirectory `json:"excluded"`
+	// WorkspaceMembers are the directories whose ecosystem was collapsed
+	// into their workspace root, sorted by path.
+	WorkspaceMembers []WorkspaceMember `json:"workspace-members"`
+	// Registries are the private registries referenced by the files of the
+	// scanned directories, sorted by path and file, if discovered through
+	// ScanOptions.PrivateRegistries.
+	Registries []RegistryReference `json:"registries"`
+}
+
+// Directory is a directory with package ecosystems.
+type Directory struct {
+	// Path is relative to the scanned root, with the root itself as "/".
+	Path string `json:"path"`
+	// Detections are the detected ecosystems, sorted by ecosystem.
+	Detections []Detection `json:"detections"`
+}
+
+// Detection records how an ecosystem was detected in a directory.
+type Detection struct {
+	Ecosystem string `json:"ecosystem"`
+	// Rule is the matching rule of the ecosystem map entry, either
+	// "patterns" or "heuristics[i]" with i the index of the heuristic.
+	Rule string `json:"rule"`
+	// Files are the matching files, relative to the directory.
+	Files []string `json:"files"`
+}
+
+// ExcludedDirectory is a directory skipped by the scan.
+type ExcludedDirectory struct {
+	// Path is relative to the scanned root.
+	Path string `json:"path"`
+	// Reason explains why the directory was skipped.
+	Reason string `json:"reason"`
+}
+
+// WorkspaceMember is a directory whose ecosystem is updated through the root
+// of its workspace.
+type WorkspaceMember struct {
+	// Path and Root are relative to the scanned root.
+	Path      string `json:"path"`
+	Ecosystem string `json:"ecosystem"`
+	Root      string `json:"root"`
+	// Files are the files which detected the ecosystem in the member,
+	// relative to its directory.
+	Files []string `json:"files"`
+}
+
+// Ecosystems returns the sorted ecosystems detected in the directory.
+func (d Directory) Ecosystems() []string {
+	var ecosystems []string
+	for _, detection := range d.Detections {
+		ecosystems = append(ecosystems, detection.Ecosystem)
+	}
+	return ecosystems
+}
+
+// Paths returns the sorted paths of the directories.
+func (r *ScanResult) Paths() []string {
+	paths := make([]string, 0, len(r.Directories))
+	for _, dir := range r.Directories {
+		paths = append(paths, dir.Path)
+	}
+	return paths
+}
+
+// EcosystemDirectories maps every detected ecosystem to its sorted directories.
+func (r *ScanResult) EcosystemDirectories() map[string][]string {
+	ecosystemDirs := make(map[string][]string)
+	for _, dir := range r.Directories {
+		for _, eco := range dir.Ecosystems() {
+			ecosystemDirs[eco] = append(ecosystemDirs[eco], dir.Path)
+		}
+	}
+	for _, dirs := range ecosystemDirs {
+		sort.Strings(dirs)
+	}
+	return ecosystemDirs
+}
+
+// sort orders the directories by path and the registries by path, file and
+// URL.
+func (r *ScanResult) sort() {
+	slices.SortFunc(r.Directories, func(a, b Directory) int {
+		return cmp.Compare(a.Path, b.Path)
+	})
+	slices.SortStableFunc(r.Registries, func(a, b RegistryReference) int {
+		return cmp.Or(cmp.Compare(a.Path, b.Path), cmp.Compare(a.File, b.File), cmp.Compare(a.URL, b.URL))
+	})
+}
+
+// owners returns the sorted code owners of the files which detected the
+// ecosystem in the directory.
+func (r *ScanResult) owners(codeOwners *CodeOwners, ecosystem, directory string) []string {
+	var files []string
+	for _, dir := range r.Directories {
+		if dir.Path != directory {
+			continue
+		}
+		for _, detection := range dir.Detections {
+			if detection.Ecosystem != ecosystem {
+				continue
+			}
+			for _, file := range detection.Files {
+				files = append(files, path.Join(slashDir(directory), file))
+			}
+		}
+	}
+
+	var owners []string
+	for _, file := range files {
+		owners = append(owners, codeOwners.Owners(file)...)
+	}
+	return sortedUnique(owners)
+}
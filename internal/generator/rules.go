@@ -0,0 +1,54 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// PathRule overrides the options of the directories matching a glob, e.g. to
+// give a subtree of a monorepo its own schedule or reviewers.
+type PathRule struct {
+	// Path is a doublestar glob of directories relative to the root, e.g.
+	// "services/payments/**", which also matches "services/payments".
+	Path string `yaml:"path"`
+	// Ecosystem restricts the rule to a package-ecosystem, if set.
+	Ecosystem string `yaml:"ecosystem,omitempty"`
+	// Schedule and Grouping replace those of the ecosystem.
+	Schedule *Schedule `yaml:"schedule,omitempty"`
+	Grouping *Grouping `yaml:"grouping,omitempty"`
+	// EntrySettings override those of the ecosystem and directory field by
+	// field.
+	EntrySettings `yaml:",inline"`
+}
+
+// Validate checks the pattern, the schedule and the grouping of the rule.
+func (r PathRule) Validate() error {
+	var errs []error
+	if r.Path == "" {
+		errs = append(errs, errors.New("path is required"))
+	} else if !doublestar.ValidatePattern(directoryKey(r.Path)) {
+		errs = append(errs, fmt.Errorf("invalid path pattern '%s'", r.Path))
+	}
+	if r.Schedule != nil {
+		if err := r.Schedule.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("invalid schedule: %w", err))
+		}
+	}
+	if r.Grouping != nil {
+		if err := r.Grouping.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("invalid grouping: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// matches reports whether the rule applies to the ecosystem in the directory.
+func (r PathRule) matches(ecosystem, directory string) bool {
+	if r.Ecosystem != "" && r.Ecosystem != ecosystem {
+		return false
+	}
+	ok, _ := doublestar.Match(directoryKey(r.Path), directoryKey(directory))
+	return ok
+}
@@ -5,73 +5,222 @@ import (
 	"fmt"
 	"io/fs"
 	"log" //nolint:depguard // No need for slog just yet.
+	"maps"
 	"os"
 	"path/filepath"
-	"sort"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
 )
 
+// ScanOptions controls which directories RecursivelyScanDirectories visits.
+type ScanOptions struct {
+	// ExcludePaths are doublestar glob patterns, anchored at the root, of
+	// paths to skip along with everything below them. Patterns prefixed with
+	// "!" re-include paths excluded by earlier patterns.
+	ExcludePaths []string
+	// RespectGitignore skips directories without any files known to git,
+	// i.e. tracked files and untracked files which are not ignored by
+	// .gitignore, .git/info/exclude or the global excludes file.
+	RespectGitignore bool
+	// TrackedOnly skips directories without any files in the git index.
+	TrackedOnly bool
+	// Workers is the number of directories inspected concurrently. Zero or
+	// less uses the number of CPUs.
+	Workers int
+	// PrivateRegistries discovers the private registries referenced by the
+	// files of the directories, see ScanResult.Registries.
+	PrivateRegistries bool
+}
+
+// scanner holds the state of a single RecursivelyScanDirectories call.
+type scanner struct {
+	root         string
+	opts         ScanOptions
+	ecosystemMap []EcosystemMapEntry
+	excludes     *excludeMatcher
+	// gitDirs are the directories, relative to root, with files known to
+	// git. It is nil unless the scan is restricted by git.
+	gitDirs map[string]struct{}
+	// candidates are the directories, relative to root, left to inspect for
+	// package ecosystems, in walk order.
+	candidates []string
+	excluded   []ExcludedDirectory
+}
+
 // RecursivelyScanDirectories walks a directory tree from a given root path,
 // identifies directories containing recognizable package ecosystems, and returns
-// a sorted list of their relative paths. It skips any directories specified
-// in ignoreDirs.
-func RecursivelyScanDirectories(root string, ignoreDirs []string, ecosystemMap []EcosystemMapEntry) ([]string, error) {
-	directoriesWithDeps := make(map[string]struct{})
+// them along with how each ecosystem was detected. It skips directories
+// according to opts. Members of npm, pnpm, yarn and Cargo workspaces are
+// collapsed into their workspace root.
+//
+// The walk itself is sequential, while the directories are inspected by a
+// pool of opts.Workers goroutines.
+func RecursivelyScanDirectories(
+	root string,
+	ecosystemMap []EcosystemMapEntry,
+	opts ScanOptions,
+) (*ScanResult, error) {
+	excludes, err := newExcludeMatcher(opts.ExcludePaths)
+	if err != nil {
+		return nil, err
+	}
 
-	walkFunc := func(path string, d fs.DirEntry, _ error) error {
-		return processDirectoryEntry(path, d, root, ignoreDirs, ecosystemMap, directoriesWithDeps)
+	s := &scanner{
+		root:         root,
+		opts:         opts,
+		ecosystemMap: ecosystemMap,
+		excludes:     excludes,
+	}
+
+	if opts.RespectGitignore || opts.TrackedOnly {
+		gitDirs, err := listGitDirectories(root, opts.TrackedOnly)
+		if err != nil {
+			return nil, err
+		}
+		s.gitDirs = gitDirs
+	}
+
+	walkFunc := func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		return s.processDirectoryEntry(path, d)
 	}
 
 	if err := filepath.WalkDir(root, walkFunc); err != nil {
 		return nil, fmt.Errorf("error walking directories: %w", err)
 	}
 
-	var result []string
-	for dir := range directoriesWithDeps {
-		result = append(result, dir)
+	result := &ScanResult{Root: root, Excluded: s.excluded}
+	for i, inspection := range s.inspectAll() {
+		if len(inspection.detections) > 0 {
+			result.Directories = append(result.Directories, Directory{
+				Path: s.candidates[i], Detections: inspection.detections,
+			})
+		}
+		for _, reference := range inspection.registries {
+			reference.Path = s.candidates[i]
+			result.Registries = append(result.Registries, reference)
+		}
+	}
+
+	result.WorkspaceMembers, err = findWorkspaceMembers(root, result.EcosystemDirectories())
+	if err != nil {
+		return nil, err
+	}
+	collapsed := make(map[string]int)
+	for i, member := range result.WorkspaceMembers {
+		collapsed[member.Ecosystem+":"+member.Path] = i
+	}
+	directories := result.Directories[:0]
+	for _, dir := range result.Directories {
+		dir.Detections = slices.DeleteFunc(dir.Detections, func(detection Detection) bool {
+			i, ok := collapsed[detection.Ecosystem+":"+dir.Path]
+			if ok {
+				result.WorkspaceMembers[i].Files = detection.Files
+			}
+			return ok
+		})
+		if len(dir.Detections) > 0 {
+			directories = append(directories, dir)
+		}
 	}
-	sort.Strings(result)
+	result.Directories = directories
+	result.sort()
 	return result, nil
 }
 
+// inspection is what the scan found in a single directory.
+type inspection struct {
+	detections []Detection
+	registries []RegistryReference
+}
+
+// inspectAll detects the package ecosystems and, if enabled, the private
+// registries of every candidate directory using a pool of workers. The result
+// is indexed like s.candidates, so it does not depend on the order in which
+// the workers finish.
+func (s *scanner) inspectAll() []inspection {
+	workers := s.opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	results := make([]inspection, len(s.candidates))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, len(s.candidates)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				path := filepath.Join(s.root, s.candidates[i])
+				detections, err := DetectEcosystems(path, s.ecosystemMap)
+				if err != nil {
+					log.Printf("Warning: could not detect ecosystems in %s: %v", path, err)
+					continue
+				}
+				results[i] = inspection{detections: detections}
+				if !s.opts.PrivateRegistries {
+					continue
+				}
+				results[i].registries, err = discoverRegistries(path)
+				if err != nil {
+					log.Printf("Warning: could not discover registries in %s: %v", path, err)
+				}
+			}
+		}()
+	}
+	for i := range s.candidates {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
 // processDirectoryEntry is a helper function for filepath.WalkDir. It processes
-// a single directory entry, checking for package ecosystems and adding them to
-// the directoriesWithDeps map if found.
-func processDirectoryEntry(
-	path string,
-	d fs.DirEntry,
-	root string,
-	ignoreDirs []string,
-	ecosystemMap []EcosystemMapEntry,
-	directoriesWithDeps map[string]struct{},
-) error {
+// a single directory entry, adding it to the candidates unless it is skipped.
+func (s *scanner) processDirectoryEntry(path string, d fs.DirEntry) error {
 	if !d.IsDir() {
 		return nil
 	}
 
-	for _, ignored := range ignoreDirs {
-		if strings.Contains(path, ignored) {
-			log.Printf("Skipping ignored directory: %s", path)
-			return filepath.SkipDir
-		}
-	}
-
-	ecosystems, err := DetectPackageEcosystems(path, ecosystemMap)
+	relPath, err := filepath.Rel(s.root, path)
 	if err != nil {
-		log.Printf("Warning: could not detect ecosystems in %s: %v", path, err)
-		return nil
+		return err
 	}
 
-	if len(ecosystems) > 0 {
-		relPath, err := filepath.Rel(root, path)
-		if err != nil {
-			return err
+	if excluded, pattern := s.excludes.excluded(filepath.ToSlash(relPath)); excluded {
+		reason := fmt.Sprintf("matches exclude pattern '%s'", pattern)
+		if s.excludes.mayIncludeBelow(filepath.ToSlash(relPath)) {
+			log.Printf("Skipping ignored directory (matches '%s'), but scanning below it: %s", pattern, path)
+			s.excluded = append(s.excluded, ExcludedDirectory{Path: relPath, Reason: reason + ", scanning below it"})
+			return nil
 		}
-		if relPath == "." {
-			relPath = "/"
+		log.Printf("Skipping ignored directory (matches '%s'): %s", pattern, path)
+		s.excluded = append(s.excluded, ExcludedDirectory{Path: relPath, Reason: reason})
+		return filepath.SkipDir
+	}
+
+	if s.gitDirs != nil {
+		if _, ok := s.gitDirs[filepath.ToSlash(relPath)]; !ok {
+			log.Printf("Skipping directory without files known to git: %s", path)
+			reason := "no files known to git"
+			if s.opts.TrackedOnly {
+				reason = "no files tracked by git"
+			}
+			s.excluded = append(s.excluded, ExcludedDirectory{Path: relPath, Reason: reason})
+			return filepath.SkipDir
 		}
-		directoriesWithDeps[relPath] = struct{}{}
 	}
+
+	if relPath == "." {
+		relPath = "/"
+	}
+	s.candidates = append(s.candidates, relPath)
 	return nil
 }
 
@@ -79,45 +228,60 @@ func processDirectoryEntry(
 // ecosystems present, based on a provided map of detection rules. It returns a
 // sorted list of all unique ecosystems found.
 func DetectPackageEcosystems(directory string, ecosystemMap []EcosystemMapEntry) ([]string, error) {
+	detections, err := DetectEcosystems(directory, ecosystemMap)
+	if err != nil {
+		return nil, err
+	}
+	return Directory{Detections: detections}.Ecosystems(), nil
+}
+
+// DetectEcosystems is like DetectPackageEcosystems, but returns how each
+// ecosystem was detected, sorted by ecosystem. Ecosystems with several
+// entries in the map are detected by the first matching entry.
+func DetectEcosystems(directory string, ecosystemMap []EcosystemMapEntry) ([]Detection, error) {
 	filesInDir, err := getFilesInDir(directory)
 	if err != nil {
 		return nil, fmt.Errorf("could not read directory %s: %w", directory, err)
 	}
 
-	foundEcosystems := make(map[string]struct{})
+	foundEcosystems := make(map[string]Detection)
 	for _, entry := range ecosystemMap {
-		var matched bool
-		var err error
+		if _, ok := foundEcosystems[entry.Ecosystem]; ok {
+			continue
+		}
 
 		if len(entry.Heuristics) > 0 {
-			matched, err = checkHeuristics(filesInDir, entry.Heuristics)
+			index, files, err := checkHeuristics(directory, filesInDir, entry.Heuristics)
 			if err != nil {
 				return nil, err
 			}
-			if matched {
+			if index >= 0 {
 				log.Printf("Detected %s in %s via heuristic", entry.Ecosystem, directory)
-				foundEcosystems[entry.Ecosystem] = struct{}{}
+				foundEcosystems[entry.Ecosystem] = Detection{
+					Ecosystem: entry.Ecosystem,
+					Rule:      fmt.Sprintf("heuristics[%d]", index),
+					Files:     files,
+				}
 				continue // First match wins
 			}
 		}
 
 		if len(entry.Patterns) > 0 {
-			matched, err = anyFileMatches(filesInDir, entry.Patterns...)
+			files, err := allMatchingFiles(directory, filesInDir, entry.Patterns...)
 			if err != nil {
 				return nil, err
 			}
-			if matched {
+			if len(files) > 0 {
 				log.Printf("Detected %s in %s via patterns", entry.Ecosystem, directory)
-				foundEcosystems[entry.Ecosystem] = struct{}{}
+				foundEcosystems[entry.Ecosystem] = Detection{Ecosystem: entry.Ecosystem, Rule: "patterns", Files: files}
 			}
 		}
 	}
 
-	var result []string
-	for eco := range foundEcosystems {
-		result = append(result, eco)
+	var result []Detection
+	for _, eco := range slices.Sorted(maps.Keys(foundEcosystems)) {
+		result = append(result, foundEcosystems[eco])
 	}
-	sort.Strings(result)
 	return result, nil
 }
 
@@ -138,56 +302,113 @@ func getFilesInDir(directory string) ([]string, error) {
 }
 
 // checkHeuristics evaluates a set of heuristic rules against the files in a
-// directory. A rule matches if all `Present` patterns are found and no `Absent`
-// patterns are found. It returns true on the first rule that matches.
-func checkHeuristics(filesInDir []string, rules []Heuristic) (bool, error) {
-	for _, rule := range rules {
+// directory. A rule matches if all `Present` patterns are found, no `Absent`
+// patterns are found, all `Content` predicates hold and no `AbsentContent`
+// predicates hold. It returns the index of the first rule that matches, along
+// with the files matching its `Present` patterns and `Content` predicates, or
+// -1 if no rule matches.
+func checkHeuristics(directory string, filesInDir []string, rules []Heuristic) (int, []string, error) {
+	for i, rule := range rules {
+		var files []string
 		presentMatch := true
 		for _, p := range rule.Present {
-			match, err := anyFileMatches(filesInDir, p)
+			matches, err := matchingFiles(directory, filesInDir, p)
 			if err != nil {
-				return false, err
+				return -1, nil, err
 			}
-			if !match {
+			if len(matches) == 0 {
 				presentMatch = false
 				break
 			}
+			files = append(files, matches...)
 		}
 
 		if !presentMatch {
 			continue
 		}
 
-		absentMatch := true
 		if len(rule.Absent) > 0 {
-			match, err := anyFileMatches(filesInDir, rule.Absent...)
+			match, err := anyFileMatches(directory, filesInDir, rule.Absent...)
 			if err != nil {
-				return false, err
+				return -1, nil, err
 			}
 			if match {
-				absentMatch = false
+				continue
 			}
 		}
 
-		if presentMatch && absentMatch {
+		contentFiles, contentMatch, err := checkContent(directory, filesInDir, rule)
+		if err != nil {
+			return -1, nil, err
+		}
+		if contentMatch {
+			return i, sortedUnique(append(files, contentFiles...)), nil
+		}
+	}
+	return -1, nil, nil
+}
+
+// anyFileMatches checks if any of the provided files match any of the given glob patterns.
+func anyFileMatches(directory string, files []string, patterns ...string) (bool, error) {
+	for _, pattern := range patterns {
+		matches, err := matchingFiles(directory, files, pattern)
+		if err != nil {
+			return false, err
+		}
+		if len(matches) > 0 {
 			return true, nil
 		}
 	}
 	return false, nil
 }
 
-// anyFileMatches checks if any of the provided files match any of the given glob patterns.
-func anyFileMatches(files []string, patterns ...string) (bool, error) {
+// allMatchingFiles returns the sorted files matching any of the given glob patterns.
+func allMatchingFiles(directory string, files []string, patterns ...string) ([]string, error) {
+	var result []string
 	for _, pattern := range patterns {
-		for _, file := range files {
-			match, err := filepath.Match(pattern, file)
+		matches, err := matchingFiles(directory, files, pattern)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, matches...)
+	}
+	return sortedUnique(result), nil
+}
+
+// sortedUnique sorts values and removes duplicates.
+func sortedUnique(values []string) []string {
+	slices.Sort(values)
+	return slices.Compact(values)
+}
+
+// matchingFiles returns the files matching the glob pattern. Patterns
+// containing a slash, such as ".github/workflows/*.yml", are matched against
+// the paths relative to directory and return such paths.
+func matchingFiles(directory string, files []string, pattern string) ([]string, error) {
+	var matches []string
+	if strings.Contains(pattern, "/") {
+		paths, err := filepath.Glob(filepath.Join(directory, filepath.FromSlash(pattern)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob pattern '%s': %w", pattern, err)
+		}
+		for _, path := range paths {
+			rel, err := filepath.Rel(directory, path)
 			if err != nil {
-				return false, fmt.Errorf("invalid glob pattern '%s': %w", pattern, err)
-			}
-			if match {
-				return true, nil
+				return nil, err
 			}
+			matches = append(matches, filepath.ToSlash(rel))
 		}
+		return matches, nil
 	}
-	return false, nil
+
+	for _, file := range files {
+		match, err := filepath.Match(pattern, file)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob pattern '%s': %w", pattern, err)
+		}
+		if match {
+			matches = append(matches, file)
+		}
+	}
+	return matches, nil
 }
@@ -0,0 +1,131 @@
+package generator
+
+import (
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// EntrySettings are the pull request settings of update entries, along with
+// which dependencies they update. Unset fields are inherited from the
+// enclosing level, see Options.settingsFor.
+type EntrySettings struct {
+	// Labels replace the inherited labels; an empty list removes them.
+	Labels []string `yaml:"labels,omitempty"`
+	// CommitMessage fields replace the inherited ones one by one.
+	CommitMessage         *CommitMessage         `yaml:"commit-message,omitempty"`
+	OpenPullRequestsLimit *int                   `yaml:"open-pull-requests-limit,omitempty"`
+	RebaseStrategy        string                 `yaml:"rebase-strategy,omitempty"`
+	Milestone             int                    `yaml:"milestone,omitempty"`
+	PullRequestBranchName *PullRequestBranchName `yaml:"pull-request-branch-name,omitempty"`
+	// Allow replaces the inherited allow-list; an empty list leaves the
+	// choice to Dependabot, which updates direct dependencies only.
+	Allow []Allow `yaml:"allow,omitempty"`
+	// Ignore rules add to the inherited ones.
+	Ignore       []Ignore `yaml:"ignore,omitempty"`
+	TargetBranch string   `yaml:"target-branch,omitempty"`
+	// Reviewers and Assignees replace the inherited ones, including those
+	// of the code owners.
+	Reviewers []string `yaml:"reviewers,omitempty"`
+	Assignees []string `yaml:"assignees,omitempty"`
+}
+
+// defaultSettings are the settings of update entries of the ecosystem without
+// any options. github-actions entries have no allow-list, as the entry always
+// generated for them before their detection had none.
+func defaultSettings(ecosystem string) EntrySettings {
+	settings := EntrySettings{Labels: []string{"dependencies"}}
+	if ecosystem != "github-actions" {
+		settings.Allow = []Allow{{DependencyType: "all"}}
+	}
+	return settings
+}
+
+// overlay returns s with the fields set in o replacing its own.
+func (s EntrySettings) overlay(o EntrySettings) EntrySettings {
+	if o.Labels != nil {
+		s.Labels = o.Labels
+	}
+	if o.CommitMessage != nil {
+		commitMessage := CommitMessage{}
+		if s.CommitMessage != nil {
+			commitMessage = *s.CommitMessage
+		}
+		if o.CommitMessage.Prefix != "" {
+			commitMessage.Prefix = o.CommitMessage.Prefix
+		}
+		if o.CommitMessage.PrefixDevelopment != "" {
+			commitMessage.PrefixDevelopment = o.CommitMessage.PrefixDevelopment
+		}
+		if o.CommitMessage.Include != "" {
+			commitMessage.Include = o.CommitMessage.Include
+		}
+		s.CommitMessage = &commitMessage
+	}
+	if o.OpenPullRequestsLimit != nil {
+		s.OpenPullRequestsLimit = o.OpenPullRequestsLimit
+	}
+	if o.RebaseStrategy != "" {
+		s.RebaseStrategy = o.RebaseStrategy
+	}
+	if o.Milestone != 0 {
+		s.Milestone = o.Milestone
+	}
+	if o.PullRequestBranchName != nil {
+		s.PullRequestBranchName = o.PullRequestBranchName
+	}
+	if o.Allow != nil {
+		s.Allow = o.Allow
+	}
+	if o.TargetBranch != "" {
+		s.TargetBranch = o.TargetBranch
+	}
+	if o.Reviewers != nil {
+		s.Reviewers = o.Reviewers
+	}
+	if o.Assignees != nil {
+		s.Assignees = o.Assignees
+	}
+	for _, ignore := range o.Ignore {
+		if !slices.ContainsFunc(s.Ignore, func(other Ignore) bool { return reflect.DeepEqual(ignore, other) }) {
+			s.Ignore = append(slices.Clip(s.Ignore), ignore)
+		}
+	}
+	return s
+}
+
+// apply sets the settings on an update entry. Its reviewers and assignees are
+// kept unless the settings have their own.
+func (s EntrySettings) apply(update *Update) {
+	if len(s.Labels) > 0 {
+		update.Labels = slices.Clone(s.Labels)
+	}
+	update.CommitMessage = s.CommitMessage
+	update.OpenPullRequestsLimit = s.OpenPullRequestsLimit
+	update.RebaseStrategy = s.RebaseStrategy
+	update.Milestone = s.Milestone
+	update.PullRequestBranchName = s.PullRequestBranchName
+	if len(s.Allow) > 0 {
+		update.Allow = slices.Clone(s.Allow)
+	}
+	update.Ignore = slices.Clone(s.Ignore)
+	update.TargetBranch = s.TargetBranch
+	if s.Reviewers != nil {
+		update.Reviewers = slices.Clone(s.Reviewers)
+	}
+	if s.Assignees != nil {
+		update.Assignees = slices.Clone(s.Assignees)
+	}
+}
+
+// directoryKey normalizes a directory for lookups, so that "/", "." and ""
+// all denote the root and "/web/" equals "web".
+func directoryKey(dir string) string {
+	dir = strings.Trim(filepath.ToSlash(dir), "/")
+	dir = strings.TrimPrefix(dir, "./")
+	if dir == "" {
+		return "."
+	}
+	return dir
+}
@@ -0,0 +1,257 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"time"
+)
+
+// scheduleTimeRegexp matches the hh:mm times of schedules.
+var scheduleTimeRegexp = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+// Validate checks the schedule against the values Dependabot accepts.
+func (s Schedule) Validate() error {
+	var errs []error
+
+	switch s.Interval {
+	case "daily", "weekly", "monthly", "quarterly", "semiannually", "yearly", "cron":
+	case "":
+		errs = append(errs, errors.New("interval is required"))
+	default:
+		errs = append(errs, fmt.Errorf("unknown interval '%s'", s.Interval))
+	}
+
+	if s.Day != "" {
+		switch s.Day {
+		case "monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday":
+		default:
+			errs = append(errs, fmt.Errorf("unknown day '%s'", s.Day))
+		}
+		if s.Interval != "weekly" {
+			errs = append(errs, fmt.Errorf("day is only supported with the weekly interval, not '%s'", s.Interval))
+		}
+	}
+
+	if s.Time != "" && !scheduleTimeRegexp.MatchString(s.Time) {
+		errs = append(errs, fmt.Errorf("time '%s' is not in the hh:mm format", s.Time))
+	}
+
+	if s.Timezone != "" {
+		if _, err := time.LoadLocation(s.Timezone); err != nil {
+			errs = append(errs, fmt.Errorf("unknown timezone '%s'", s.Timezone))
+		}
+	}
+
+	switch {
+	case s.Interval == "cron" && s.Cronjob == "":
+		errs = append(errs, errors.New("cronjob is required with the cron interval"))
+	case s.Interval != "cron" && s.Cronjob != "":
+		errs = append(errs, fmt.Errorf("cronjob is only supported with the cron interval, not '%s'", s.Interval))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Validate checks the configuration against the rules of the Dependabot v2
+// configuration format. Every problem is reported with the offending entry.
+func (c *DependabotConfig) Validate() error {
+	var errs []error
+
+	if c.Version != 2 {
+		errs = append(errs, fmt.Errorf("version must be 2, got %d", c.Version))
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.Registries)) {
+		registry := c.Registries[name]
+		if !isKnownRegistryType(registry.Type) {
+			errs = append(errs, fmt.Errorf("registries.%s: unknown type '%s'", name, registry.Type))
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.MultiEcosystemGroups)) {
+		if err := c.MultiEcosystemGroups[name].Schedule.Validate(); err != nil {
+			for _, scheduleErr := range flattenErrors(err) {
+				errs = append(errs, fmt.Errorf("multi-ecosystem-groups.%s: schedule: %w", name, scheduleErr))
+			}
+		}
+	}
+
+	seen := make(map[string]int)
+	for i, update := range c.Updates {
+		prefix := fmt.Sprintf("updates[%d] (%s in %v)", i, update.PackageEcosystem, updateDirectories(update))
+		for _, err := range c.validateUpdate(update) {
+			errs = append(errs, fmt.Errorf("%s: %w", prefix, err))
+		}
+
+		for _, dir := range updateDirectories(update) {
+			key := update.PackageEcosystem + ":" + normalizeDirectory(dir) + ":" + update.TargetBranch
+			if j, ok := seen[key]; ok {
+				errs = append(errs, fmt.Errorf("%s: directory '%s' is already covered by updates[%d]", prefix, dir, j))
+			}
+			seen[key] = i
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateUpdate returns every problem of a single update entry.
+func (c *DependabotConfig) validateUpdate(update Update) []error {
+	var errs []error
+
+	switch {
+	case update.PackageEcosystem == "":
+		errs = append(errs, errors.New("package-ecosystem is required"))
+	case !isKnownEcosystem(update.PackageEcosystem):
+		errs = append(errs, fmt.Errorf("unknown package-ecosystem '%s'", update.PackageEcosystem))
+	}
+
+	switch {
+	case update.Directory == "" && len(update.Directories) == 0:
+		errs = append(errs, errors.New("one of directory or directories is required"))
+	case update.Directory != "" && len(update.Directories) > 0:
+		errs = append(errs, errors.New("directory and directories are mutually exclusive"))
+	}
+
+	// Entries of a multi-ecosystem group may use the schedule of the group.
+	if update.MultiEcosystemGroup == "" || update.Schedule != (Schedule{}) {
+		if err := update.Schedule.Validate(); err != nil {
+			for _, scheduleErr := range flattenErrors(err) {
+				errs = append(errs, fmt.Errorf("schedule: %w", scheduleErr))
+			}
+		}
+	}
+
+	if update.MultiEcosystemGroup != "" {
+		if _, ok := c.MultiEcosystemGroups[update.MultiEcosystemGroup]; !ok {
+			errs = append(errs, fmt.Errorf(
+				"multi-ecosystem-group '%s' is not defined in the top-level multi-ecosystem-groups",
+				update.MultiEcosystemGroup))
+		}
+		if len(update.Patterns) == 0 {
+			errs = append(errs, errors.New("patterns are required with multi-ecosystem-group"))
+		}
+	}
+
+	for _, name := range update.Registries {
+		if _, ok := c.Registries[name]; !ok && name != "*" {
+			errs = append(errs, fmt.Errorf("registry '%s' is not defined in the top-level registries", name))
+		}
+	}
+
+	for i, allow := range update.Allow {
+		if allow.DependencyName == "" && allow.DependencyType == "" {
+			errs = append(errs, fmt.Errorf("allow[%d]: one of dependency-name or dependency-type is required", i))
+		}
+		if !oneOf(allow.DependencyType, "", "direct", "indirect", "all", "production", "development") {
+			errs = append(errs, fmt.Errorf("allow[%d]: unknown dependency-type '%s'", i, allow.DependencyType))
+		}
+	}
+
+	for i, ignore := range update.Ignore {
+		if ignore.DependencyName == "" {
+			errs = append(errs, fmt.Errorf("ignore[%d]: dependency-name is required", i))
+		}
+		for _, updateType := range ignore.UpdateTypes {
+			if !oneOf(updateType, "version-update:semver-major", "version-update:semver-minor",
+				"version-update:semver-patch") {
+				errs = append(errs, fmt.Errorf("ignore[%d]: unknown update-type '%s'", i, updateType))
+			}
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(update.Groups)) {
+		for _, err := range validateGroup(update.Groups[name]) {
+			errs = append(errs, fmt.Errorf("groups.%s: %w", name, err))
+		}
+	}
+
+	return append(errs, validateUpdateOptions(update)...)
+}
+
+// validateGroup returns every problem of a group definition.
+func validateGroup(group Group) []error {
+	var errs []error
+	if len(group.Patterns) == 0 && len(group.ExcludePatterns) == 0 && group.DependencyType == "" &&
+		len(group.UpdateTypes) == 0 {
+		errs = append(errs, errors.New("one of patterns, exclude-patterns, dependency-type or update-types is required"))
+	}
+	if !oneOf(group.AppliesTo, "", "version-updates", "security-updates") {
+		errs = append(errs, fmt.Errorf("unknown applies-to '%s'", group.AppliesTo))
+	}
+	if !oneOf(group.DependencyType, "", "development", "production") {
+		errs = append(errs, fmt.Errorf("unknown dependency-type '%s'", group.DependencyType))
+	}
+	if !oneOf(group.GroupBy, "", "dependency-name") {
+		errs = append(errs, fmt.Errorf("unknown group-by '%s'", group.GroupBy))
+	}
+	for _, updateType := range group.UpdateTypes {
+		if !oneOf(updateType, "major", "minor", "patch") {
+			errs = append(errs, fmt.Errorf("unknown update-type '%s'", updateType))
+		}
+	}
+	return errs
+}
+
+// validateUpdateOptions returns problems with the enumerated options of an update entry.
+func validateUpdateOptions(update Update) []error {
+	var errs []error
+	if !oneOf(update.RebaseStrategy, "", "auto", "disabled") {
+		errs = append(errs, fmt.Errorf("unknown rebase-strategy '%s'", update.RebaseStrategy))
+	}
+	if !oneOf(update.VersioningStrategy, "", "auto", "increase", "increase-if-necessary", "lockfile-only", "widen") {
+		errs = append(errs, fmt.Errorf("unknown versioning-strategy '%s'", update.VersioningStrategy))
+	}
+	if !oneOf(update.InsecureExternalCodeExecution, "", "allow", "deny") {
+		errs = append(errs, fmt.Errorf(
+			"unknown insecure-external-code-execution '%s'", update.InsecureExternalCodeExecution))
+	}
+	if update.OpenPullRequestsLimit != nil && *update.OpenPullRequestsLimit < 0 {
+		errs = append(errs, fmt.Errorf("open-pull-requests-limit must not be negative, got %d",
+			*update.OpenPullRequestsLimit))
+	}
+	if update.CommitMessage != nil && !oneOf(update.CommitMessage.Include, "", "scope") {
+		errs = append(errs, fmt.Errorf("unknown commit-message include '%s'", update.CommitMessage.Include))
+	}
+	if update.PullRequestBranchName != nil && !oneOf(update.PullRequestBranchName.Separator, "-", "_", "/") {
+		errs = append(errs, fmt.Errorf(
+			"unknown pull-request-branch-name separator '%s'", update.PullRequestBranchName.Separator))
+	}
+	return errs
+}
+
+// isKnownEcosystem reports whether Dependabot supports the package ecosystem.
+func isKnownEcosystem(ecosystem string) bool {
+	return oneOf(ecosystem,
+		"bun", "bundler", "cargo", "composer", "conda", "devcontainers", "docker", "docker-compose",
+		"dotnet-sdk", "elm", "github-actions", "gitsubmodule", "gomod", "gradle", "helm", "julia", "maven",
+		"mix", "npm", "nuget", "opentofu", "pip", "pre-commit", "pub", "rust-toolchain", "swift",
+		"terraform", "uv", "vcpkg",
+	)
+}
+
+// isKnownRegistryType reports whether Dependabot supports the registry type.
+func isKnownRegistryType(registryType string) bool {
+	return oneOf(registryType,
+		"cargo-registry", "composer-repository", "docker-registry", "git", "goproxy-server",
+		"helm-registry", "hex-organization", "hex-repository", "maven-repository", "npm-registry",
+		"nuget-feed", "pub-repository", "python-index", "rubygems-server", "terraform-registry",
+	)
+}
+
+// oneOf reports whether value is one of the allowed values.
+func oneOf(value string, allowed ...string) bool {
+	return slices.Contains(allowed, value)
+}
+
+// flattenErrors splits an error created by errors.Join into its parts.
+func flattenErrors(err error) []error {
+	var joined interface{ Unwrap() []error }
+	if errors.As(err, &joined) {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
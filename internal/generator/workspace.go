@@ -0,0 +1,255 @@
+package generator
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log" //nolint:depguard // No need for slog just yet.
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/bmatcuk/doublestar/v4"
+	"gopkg.in/yaml.v3"
+)
+
+// workspace is a workspace defined in a directory, such as npm workspaces or
+// a Cargo workspace. Dependabot updates the members of a workspace through
+// its root, which holds the shared lockfile.
+type workspace struct {
+	// ecosystems are the package ecosystems the workspace applies to.
+	ecosystems []string
+	// members are glob patterns, relative to the workspace root, of the
+	// member directories.
+	members []string
+	// excludes are glob patterns of directories which are not members, even
+	// though they match members.
+	excludes []string
+}
+
+// claims reports whether the directory rel, relative to the workspace root,
+// is a member of the workspace for the given ecosystem.
+func (w workspace) claims(ecosystem, rel string) bool {
+	if !slices.Contains(w.ecosystems, ecosystem) {
+		return false
+	}
+	matches := func(patterns []string) bool {
+		return slices.ContainsFunc(patterns, func(pattern string) bool {
+			return doublestar.MatchUnvalidated(pattern, rel)
+		})
+	}
+	return matches(w.members) && !matches(w.excludes)
+}
+
+// findWorkspaceMembers returns the workspace members in ecosystemDirs, which
+// maps ecosystems to directories relative to root, sorted by path and
+// ecosystem. Members are only collapsed into a root which is itself listed
+// for the same ecosystem, as Dependabot needs a manifest there. Nested
+// members are collapsed into the outermost root.
+func findWorkspaceMembers(root string, ecosystemDirs map[string][]string) ([]WorkspaceMember, error) {
+	cache := make(map[string][]workspace)
+	workspacesIn := func(dir string) ([]workspace, error) {
+		if workspaces, ok := cache[dir]; ok {
+			return workspaces, nil
+		}
+		workspaces, err := readWorkspaces(filepath.Join(root, filepath.FromSlash(dir)))
+		if err != nil {
+			return nil, err
+		}
+		cache[dir] = workspaces
+		return workspaces, nil
+	}
+
+	var members []WorkspaceMember
+	for _, eco := range slices.Sorted(maps.Keys(ecosystemDirs)) {
+		dirs := ecosystemDirs[eco]
+		listed := make(map[string]struct{}, len(dirs))
+		for _, dir := range dirs {
+			listed[slashDir(dir)] = struct{}{}
+		}
+
+		for _, dir := range dirs {
+			workspaceRoot, err := workspaceRootOf(eco, slashDir(dir), listed, workspacesIn)
+			if err != nil {
+				return nil, err
+			}
+			if workspaceRoot == "" {
+				continue
+			}
+			log.Printf("Collapsing %s workspace member %s into %s", eco, dir, fromSlashDir(workspaceRoot))
+			members = append(members, WorkspaceMember{Path: dir, Ecosystem: eco, Root: fromSlashDir(workspaceRoot)})
+		}
+	}
+	slices.SortStableFunc(members, func(a, b WorkspaceMember) int {
+		return cmp.Compare(a.Path, b.Path)
+	})
+	return members, nil
+}
+
+// workspaceRootOf returns the outermost listed directory among the parents
+// of dir, all slash-separated and relative to the scan root, with a
+// workspace claiming dir for the given ecosystem. It returns an empty string
+// if dir is not a workspace member.
+func workspaceRootOf(
+	ecosystem, dir string,
+	listed map[string]struct{},
+	workspacesIn func(dir string) ([]workspace, error),
+) (string, error) {
+	var workspaceRoot string
+	for parent := dir; parent != "."; {
+		parent = path.Dir(parent)
+		if _, ok := listed[parent]; !ok {
+			continue
+		}
+		workspaces, err := workspacesIn(parent)
+		if err != nil {
+			return "", err
+		}
+		rel := strings.TrimPrefix(dir, parent+"/")
+		if parent == "." {
+			rel = dir
+		}
+		for _, w := range workspaces {
+			if w.claims(ecosystem, rel) {
+				workspaceRoot = parent
+			}
+		}
+	}
+	return workspaceRoot, nil
+}
+
+// slashDir converts a directory as returned by RecursivelyScanDirectories to
+// a slash-separated path relative to the scan root.
+func slashDir(dir string) string {
+	if dir == "/" {
+		return "."
+	}
+	return filepath.ToSlash(dir)
+}
+
+// fromSlashDir is the inverse of slashDir.
+func fromSlashDir(dir string) string {
+	if dir == "." {
+		return "/"
+	}
+	return filepath.FromSlash(dir)
+}
+
+// readWorkspaces returns the workspaces defined in a directory by
+// package.json, pnpm-workspace.yaml and Cargo.toml. Go workspaces are left
+// out, as Dependabot does not read go.work and needs every module listed.
+func readWorkspaces(directory string) ([]workspace, error) {
+	readers := []struct {
+		file string
+		read func(data []byte) (*workspace, error)
+	}{
+		{"package.json", readPackageJSONWorkspace},
+		{"pnpm-workspace.yaml", readPnpmWorkspace},
+		{"Cargo.toml", readCargoWorkspace},
+	}
+
+	var workspaces []workspace
+	for _, reader := range readers {
+		data, err := os.ReadFile(filepath.Join(directory, reader.file))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s: %w", reader.file, err)
+		}
+		w, err := reader.read(data)
+		if err != nil {
+			log.Printf("Warning: could not parse %s in %s: %v", reader.file, directory, err)
+			continue
+		}
+		if w != nil {
+			workspaces = append(workspaces, *w)
+		}
+	}
+	return workspaces, nil
+}
+
+// readPackageJSONWorkspace reads the workspaces of npm, yarn and bun, given
+// either as a list of patterns or as an object with a packages list.
+func readPackageJSONWorkspace(data []byte) (*workspace, error) {
+	var manifest struct {
+		Workspaces json.RawMessage `json:"workspaces"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	if len(manifest.Workspaces) == 0 {
+		return nil, nil //nolint:nilnil // No workspaces is not an error.
+	}
+
+	var patterns []string
+	if err := json.Unmarshal(manifest.Workspaces, &patterns); err != nil {
+		var object struct {
+			Packages []string `json:"packages"`
+		}
+		if err := json.Unmarshal(manifest.Workspaces, &object); err != nil {
+			return nil, errors.New("workspaces must be a list of patterns or an object with packages")
+		}
+		patterns = object.Packages
+	}
+	return newWorkspace([]string{"npm", "bun"}, patterns, nil), nil
+}
+
+// readPnpmWorkspace reads the packages of a pnpm workspace.
+func readPnpmWorkspace(data []byte) (*workspace, error) {
+	var manifest struct {
+		Packages []string `yaml:"packages"`
+	}
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	return newWorkspace([]string{"npm"}, manifest.Packages, nil), nil
+}
+
+// readCargoWorkspace reads the members of a Cargo workspace.
+func readCargoWorkspace(data []byte) (*workspace, error) {
+	var manifest struct {
+		Workspace *struct {
+			Members []string `toml:"members"`
+			Exclude []string `toml:"exclude"`
+		} `toml:"workspace"`
+	}
+	if err := toml.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Workspace == nil {
+		return nil, nil //nolint:nilnil // No workspace is not an error.
+	}
+	return newWorkspace([]string{"cargo"}, manifest.Workspace.Members, manifest.Workspace.Exclude), nil
+}
+
+// newWorkspace returns a workspace with normalized patterns. Patterns
+// prefixed with "!" are moved to the excludes. It returns nil if there are
+// no member patterns.
+func newWorkspace(ecosystems, patterns, excludes []string) *workspace {
+	w := &workspace{ecosystems: ecosystems}
+	normalize := func(pattern string) string {
+		pattern = strings.TrimPrefix(strings.TrimSpace(pattern), "./")
+		return strings.Trim(path.Clean(pattern), "/")
+	}
+	for _, pattern := range patterns {
+		if excluded, ok := strings.CutPrefix(pattern, "!"); ok {
+			w.excludes = append(w.excludes, normalize(excluded))
+			continue
+		}
+		w.members = append(w.members, normalize(pattern))
+	}
+	for _, pattern := range excludes {
+		w.excludes = append(w.excludes, normalize(pattern))
+	}
+	if len(w.members) == 0 {
+		return nil
+	}
+	return w
+}